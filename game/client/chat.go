@@ -0,0 +1,118 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
+	"github.com/livingpool/top-down-shooter/game/util"
+)
+
+// In-game chat.
+//
+// Enter starts typing a message to everyone, and T one to the player's team.
+// Enter sends it, and Escape cancels it. While typing, the player doesn't move.
+// The last util.ChatHistorySize messages are drawn over the game for util.ChatDisplayPeriod.
+
+// chatLine is a received message and when it arrived.
+type chatLine struct {
+	chat       state.Chat
+	receivedAt time.Time
+}
+
+type chatBox struct {
+	typing  bool
+	channel string
+	text    []rune
+	lines   []chatLine
+}
+
+// ReceiveChat queues a chat message to be shown on the next Update.
+// It is safe to call from the goroutine reading the connection.
+func (c *Client) ReceiveChat(chat state.Chat) {
+	select {
+	case c.chatMessages <- chat:
+	default:
+		// the overlay only shows the last few messages anyway
+	}
+}
+
+func (c *Client) receiveChats(now time.Time) {
+	for len(c.chatMessages) > 0 {
+		c.chat.lines = append(c.chat.lines, chatLine{chat: <-c.chatMessages, receivedAt: now})
+	}
+	if n := len(c.chat.lines); n > util.ChatHistorySize {
+		c.chat.lines = c.chat.lines[n-util.ChatHistorySize:]
+	}
+}
+
+// updateChatInput handles the keys of the chat box, and reports whether the player is typing.
+func (c *Client) updateChatInput() bool {
+	if c.SendChat == nil {
+		return false
+	}
+
+	if !c.chat.typing {
+		switch {
+		case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+			c.chat.typing, c.chat.channel = true, util.ChatChannelAll
+		case inpututil.IsKeyJustPressed(ebiten.KeyT):
+			c.chat.typing, c.chat.channel = true, util.ChatChannelTeam
+		default:
+			return false
+		}
+		c.chat.text = c.chat.text[:0]
+		return true
+	}
+
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		c.chat.typing = false
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		c.chat.typing = false
+		if text := strings.TrimSpace(string(c.chat.text)); text != "" {
+			c.SendChat(state.Chat{PlayerId: c.LocalPlayerID.String(), Channel: c.chat.channel, Text: text})
+		}
+	case inpututil.IsKeyJustPressed(ebiten.KeyBackspace):
+		if len(c.chat.text) > 0 {
+			c.chat.text = c.chat.text[:len(c.chat.text)-1]
+		}
+	default:
+		c.chat.text = ebiten.AppendInputChars(c.chat.text)
+		if len(c.chat.text) > util.MaxChatLength {
+			c.chat.text = c.chat.text[:util.MaxChatLength]
+		}
+	}
+
+	return true
+}
+
+// drawChat draws the recent messages, and the one being typed, in the bottom left corner.
+func (c *Client) drawChat(screen *ebiten.Image, now time.Time) {
+	const lineHeight = 16
+
+	lines := make([]string, 0, len(c.chat.lines)+1)
+	for _, line := range c.chat.lines {
+		if now.Sub(line.receivedAt) > util.ChatDisplayPeriod && !c.chat.typing {
+			continue
+		}
+		prefix := ""
+		if line.chat.Channel == util.ChatChannelTeam {
+			prefix = "[team] "
+		}
+		lines = append(lines, fmt.Sprintf("%v%v: %v", prefix, line.chat.Name, line.chat.Text))
+	}
+	if c.chat.typing {
+		lines = append(lines, fmt.Sprintf("(%v) > %v_", c.chat.channel, string(c.chat.text)))
+	}
+
+	y := util.ScreenHeight - lineHeight*(len(lines)+1)
+	for _, line := range lines {
+		ebitenutil.DebugPrintAt(screen, line, 8, y)
+		y += lineHeight
+	}
+}
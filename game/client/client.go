@@ -0,0 +1,108 @@
+// Package client is the game as a player sees it.
+// It renders a game.Game with ebiten, and turns the player's keys into inputs for it.
+// Nothing else may depend on ebiten, so the server can run headless.
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/livingpool/top-down-shooter/game/game"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
+	"github.com/livingpool/top-down-shooter/game/util"
+)
+
+// Client implements ebiten.Game for a game.Game joined or watched over the network.
+type Client struct {
+	*game.Game
+
+	SendChat     func(state.Chat) // sends a chat message typed by the player
+	chatMessages chan state.Chat  // chat messages received from the server, shown on the next Update
+	chat         chatBox
+	failed       chan error // the error the game ends with on the next Update, see Fail
+}
+
+func New(g *game.Game) *Client {
+	return &Client{
+		Game:         g,
+		chatMessages: make(chan state.Chat, util.ChatHistorySize),
+		failed:       make(chan error, 1),
+	}
+}
+
+// Fail ends the game with err on the next Update, e.g. when the connection to the server is lost.
+// It is safe to call from any goroutine; only the first error is kept.
+func (c *Client) Fail(err error) {
+	select {
+	case c.failed <- err:
+	default:
+	}
+}
+
+func (c *Client) Update() error {
+	select {
+	case err := <-c.failed:
+		return err
+	default:
+	}
+
+	now := c.Clock.Now()
+	c.receiveChats(now)
+
+	// keys typed into the chat box don't move the player
+	var keys util.KeyPress
+	if !c.updateChatInput() {
+		keys = readKeyPress()
+	}
+	c.UpdateClient(keys, now)
+
+	return nil
+}
+
+func readKeyPress() util.KeyPress {
+	return util.KeyPress{
+		W:     ebiten.IsKeyPressed(ebiten.KeyW),
+		S:     ebiten.IsKeyPressed(ebiten.KeyS),
+		A:     ebiten.IsKeyPressed(ebiten.KeyA),
+		D:     ebiten.IsKeyPressed(ebiten.KeyD),
+		Space: ebiten.IsKeyPressed(ebiten.KeySpace),
+	}
+}
+
+func (c *Client) Draw(screen *ebiten.Image) {
+	for _, p := range c.Players {
+		drawPlayer(screen, p, c.DebugMode)
+	}
+	for _, b := range c.Bullets {
+		drawBullet(screen, b, c.DebugMode)
+	}
+	for _, z := range c.Zombies {
+		drawZombie(screen, z)
+	}
+
+	if c.Phase != game.PhaseInProgress {
+		ebitenutil.DebugPrint(screen, c.phaseText())
+	}
+	c.drawChat(screen, c.Clock.Now())
+}
+
+func (c *Client) phaseText() string {
+	switch c.Phase {
+	case game.PhaseWaiting:
+		return "Waiting for players..."
+	case game.PhaseCountdown:
+		return fmt.Sprintf("Starting in %v", max(c.PhaseEndsAt.Sub(c.ServerTime(c.Clock.Now())).Round(time.Second), 0))
+	case game.PhaseRoundOver:
+		return "Round over!"
+	case game.PhaseClosed:
+		return "The game has been closed."
+	default:
+		return ""
+	}
+}
+
+func (c *Client) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return util.ScreenWidth, util.ScreenHeight
+}
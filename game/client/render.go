@@ -0,0 +1,64 @@
+package client
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/livingpool/top-down-shooter/game/assets"
+	"github.com/livingpool/top-down-shooter/game/pkg/bullet"
+	"github.com/livingpool/top-down-shooter/game/pkg/player"
+	"github.com/livingpool/top-down-shooter/game/pkg/spawner"
+	"github.com/livingpool/top-down-shooter/game/util"
+)
+
+func drawPlayer(screen *ebiten.Image, p *player.Player, debugMode bool) {
+	sprite := assets.ManBlueGunSprite
+
+	op := centerAndRotate(sprite, p.Object.Rotation)
+	op.GeoM.Translate(p.Object.X, p.Object.Y)
+	screen.DrawImage(sprite, op)
+
+	if debugMode {
+		drawDebugCircle(screen, p.Object.Vector, 32)
+	}
+}
+
+func drawBullet(screen *ebiten.Image, b *bullet.Bullet, debugMode bool) {
+	sprite := assets.Bullet
+
+	op := centerAndRotate(sprite, b.Object.Rotation)
+	op.GeoM.Scale(0.5, 0.5)
+	op.GeoM.Translate(b.Object.X, b.Object.Y)
+	screen.DrawImage(sprite, op)
+
+	if debugMode {
+		drawDebugCircle(screen, b.Object.Vector, 4)
+	}
+}
+
+func drawZombie(screen *ebiten.Image, z *spawner.Zombie) {
+	sprite := assets.Zombie1HoldSprite
+
+	op := centerAndRotate(sprite, z.Object.Rotation)
+	op.GeoM.Translate(z.Object.X, z.Object.Y)
+	screen.DrawImage(sprite, op)
+}
+
+// Return a *ebiten.DrawImageOptions where the sprite is centered at (0,0) and rotated.
+func centerAndRotate(sprite *ebiten.Image, rotation float64) *ebiten.DrawImageOptions {
+	bounds := sprite.Bounds()
+	halfW := float64(bounds.Dx()) / 2
+	halfH := float64(bounds.Dy()) / 2
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-halfW, -halfH)
+	op.GeoM.Rotate(rotation)
+
+	return op
+}
+
+func drawDebugCircle(screen *ebiten.Image, v util.Vector, radius float32) {
+	c := color.RGBA{R: 74, G: 246, B: 38, A: 1}
+	vector.StrokeCircle(screen, float32(v.X), float32(v.Y), radius, 1, c, true)
+}
@@ -1,23 +1,318 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
 
+	"github.com/coder/websocket"
+	"github.com/google/uuid"
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/livingpool/top-down-shooter/game/client"
 	"github.com/livingpool/top-down-shooter/game/game"
-	"github.com/livingpool/top-down-shooter/game/pkg/player"
+	"github.com/livingpool/top-down-shooter/game/pkg/protocol"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
+	"github.com/livingpool/top-down-shooter/game/util"
+)
+
+var (
+	serverURL = flag.String("server", "http://localhost:42069", "game server url")
+	name      = flag.String("name", "", "player name")
+	gameId    = flag.String("game", "", "id of an existing game to join, creates a new game if empty")
+	playerId  = flag.String("player", "", "id of an existing player in the game to play as, joins as a new player if empty")
+	token     = flag.String("token", "", "session token of the existing player given by -player")
+	start     = flag.Bool("start", false, "start the game right away instead of waiting for others to join")
+	wire      = flag.String("protocol", "binary", "wire protocol to ask the server for, binary or json")
+	spectate  = flag.Bool("spectate", false, "watch the game given by -game without playing")
 )
 
 func main() {
-	g := &game.Game{
-		DebugMode: true,
-		Players:   []*player.Player{player.NewPlayer("todo")},
+	flag.Parse()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	g := game.NewGame(false)
+	c := client.New(g)
+
+	var conn *websocket.Conn
+	if *spectate {
+		if *gameId == "" {
+			log.Fatal("flag -game is required to spectate")
+		}
+		g.ID = uuid.MustParse(*gameId)
+
+		var err error
+		conn, err = watch(ctx, *serverURL, *gameId, *wire)
+		if err != nil {
+			log.Fatalf("error spectating game: %v", err)
+		}
+	} else {
+		ids := joinOrCreate()
+		g.ID = uuid.MustParse(ids.GameId)
+		g.LocalPlayerID = uuid.MustParse(ids.PlayerId)
+
+		var err error
+		conn, err = join(ctx, *serverURL, ids, *wire)
+		if err != nil {
+			log.Fatalf("error joining game: %v", err)
+		}
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+
+	// the server falls back to json if it doesn't speak the protocol we asked for
+	codec := protocol.ForSubprotocol(conn.Subprotocol())
+
+	// messages are written by their own goroutine, so the game loop never waits on the network.
+	// The goroutines hand their errors to the game loop, which ends the game with them
+	outbound := make(chan state.Msg, util.ClientUpdateBufferSize)
+	if !*spectate {
+		g.SendInput = func(input util.ClientUpdate) {
+			enqueue(outbound, state.Msg{Type: util.MsgTypeInput, Payload: input}, c.Fail)
+		}
+		g.SendAck = func(ack util.SnapshotAck) {
+			enqueue(outbound, state.Msg{Type: util.MsgTypeAck, Payload: ack}, c.Fail)
+		}
+		c.SendChat = func(chat state.Chat) {
+			enqueue(outbound, state.Msg{Type: util.MsgTypeChat, Payload: chat}, c.Fail)
+		}
+		go writeMessages(ctx, conn, codec, outbound, c.Fail)
+	}
+	go readMessages(ctx, conn, codec, newRouter(c, outbound), c.Fail)
+
+	if *start {
+		if err := startGame(*serverURL, g.ID.String()); err != nil {
+			log.Fatalf("error starting game: %v", err)
+		}
 	}
 
 	ebiten.SetWindowTitle("Tim's Top Down Shooter <3")
 
-	err := ebiten.RunGame(g)
+	err := ebiten.RunGame(c)
 	if err != nil {
 		log.Fatalf("error running the game: %v", err)
 	}
 }
+
+// joinOrCreate returns the ids and token of the player to play as,
+// creating a new game or adding a new player to an existing one as the flags ask.
+func joinOrCreate() util.CreatePlayerResp {
+	if *name == "" {
+		log.Fatal("flag -name is required")
+	}
+
+	ids := util.CreatePlayerResp{GameId: *gameId, PlayerId: *playerId, Token: *token}
+	if ids.PlayerId != "" && ids.Token == "" {
+		log.Fatal("flag -token is required to play as an existing player")
+	}
+	if ids.GameId == "" {
+		var err error
+		ids, err = createGame(*serverURL, *name)
+		if err != nil {
+			log.Fatalf("error creating game: %v", err)
+		}
+		log.Printf("created game %v, share this id for others to join", ids.GameId)
+	} else if ids.PlayerId == "" {
+		var err error
+		ids, err = addPlayer(*serverURL, ids.GameId, *name)
+		if err != nil {
+			log.Fatalf("error adding player to game: %v", err)
+		}
+	}
+	if *playerId == "" {
+		log.Printf("to reconnect, use -game %v -player %v -token %v", ids.GameId, ids.PlayerId, ids.Token)
+	}
+
+	return ids
+}
+
+// createGame calls /create and returns the ids of the new game and its first player.
+func createGame(serverURL, name string) (util.CreatePlayerResp, error) {
+	resp, err := http.Get(serverURL + "/create?" + url.Values{"name": {name}}.Encode())
+	if err != nil {
+		return util.CreatePlayerResp{}, err
+	}
+	return readCreatePlayerResp(resp)
+}
+
+// addPlayer adds a new player to an existing game and returns the ids.
+func addPlayer(serverURL, gameId, name string) (util.CreatePlayerResp, error) {
+	endpoint := serverURL + "/games/" + url.PathEscape(gameId) + "/players?" + url.Values{"name": {name}}.Encode()
+	resp, err := http.Post(endpoint, "", nil)
+	if err != nil {
+		return util.CreatePlayerResp{}, err
+	}
+	return readCreatePlayerResp(resp)
+}
+
+// startGame asks the server to start the game's countdown.
+func startGame(serverURL, gameId string) error {
+	resp, err := http.Post(serverURL+"/games/"+url.PathEscape(gameId)+"/start", "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %v: %s", resp.Status, data)
+	}
+
+	return nil
+}
+
+func readCreatePlayerResp(resp *http.Response) (util.CreatePlayerResp, error) {
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return util.CreatePlayerResp{}, err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return util.CreatePlayerResp{}, fmt.Errorf("unexpected status %v: %s", resp.Status, data)
+	}
+
+	var createResp util.CreatePlayerResp
+	if err := json.Unmarshal(data, &createResp); err != nil {
+		return util.CreatePlayerResp{}, err
+	}
+
+	return createResp, nil
+}
+
+// join dials /join, which upgrades the connection to a websocket.
+func join(ctx context.Context, serverURL string, ids util.CreatePlayerResp, wire string) (*websocket.Conn, error) {
+	return dial(ctx, serverURL+"/join?"+url.Values{"token": {ids.Token}}.Encode(), wire)
+}
+
+// watch dials /spectate. Spectators must never write to the connection.
+func watch(ctx context.Context, serverURL, gameId, wire string) (*websocket.Conn, error) {
+	return dial(ctx, serverURL+"/spectate?"+url.Values{"game_id": {gameId}}.Encode(), wire)
+}
+
+// dial opens a websocket, offering wire to the server as a subprotocol, see protocol.Subprotocols.
+func dial(ctx context.Context, endpoint, wire string) (*websocket.Conn, error) {
+	subprotocol := protocol.SubprotocolBinary
+	if wire == "json" {
+		subprotocol = protocol.SubprotocolJSON
+	}
+
+	conn, _, err := websocket.Dial(ctx, endpoint, &websocket.DialOptions{
+		Subprotocols: []string{subprotocol},
+	})
+	return conn, err
+}
+
+// enqueue queues the message for writeMessages without ever blocking the game loop.
+// The queue is only full if the connection is stuck: acks and pongs are dropped then, since a later one supersedes them,
+// but anything else would be lost for good, so the game fails instead.
+func enqueue(outbound chan<- state.Msg, msg state.Msg, fail func(error)) {
+	select {
+	case outbound <- msg:
+	default:
+		if msg.Type == util.MsgTypeAck || msg.Type == util.MsgTypePong {
+			slog.Debug("outbound queue is full, dropping message", "type", msg.Type)
+			return
+		}
+		fail(fmt.Errorf("outbound queue is full, can't send %v", msg.Type))
+	}
+}
+
+// writeMessages writes the messages queued by the game and the router, e.g. inputs and snapshot acks,
+// until the connection fails.
+func writeMessages(ctx context.Context, conn *websocket.Conn, codec protocol.Codec, outbound <-chan state.Msg, fail func(error)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-outbound:
+			data, err := codec.Encode(msg)
+			if err != nil {
+				slog.Error("error encoding message", "type", msg.Type, "err", err)
+				continue
+			}
+			if err := conn.Write(ctx, codec.MessageType(), data); err != nil {
+				fail(fmt.Errorf("error sending message: %w", err))
+				return
+			}
+		}
+	}
+}
+
+// readMessages routes every message from the server until the connection is lost.
+// Messages that can't be decoded or routed are logged and skipped.
+func readMessages(ctx context.Context, conn *websocket.Conn, codec protocol.Codec, router *protocol.Router, fail func(error)) {
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			fail(fmt.Errorf("connection to server lost: %w", err))
+			return
+		}
+
+		msg, err := codec.Decode(data)
+		if err == nil {
+			err = router.Route(msg)
+		}
+		if err != nil {
+			slog.Warn("rejected server message", "type", msg.Type, "err", err)
+		}
+	}
+}
+
+// newRouter routes the messages the server sends to the client.
+func newRouter(c *client.Client, outbound chan<- state.Msg) *protocol.Router {
+	router := protocol.NewRouter()
+
+	protocol.Handle(router, util.MsgTypeSnapshot, func(update state.ServerUpdate) error {
+		c.ReceiveServerUpdate(update)
+		return nil
+	})
+
+	protocol.Handle(router, util.MsgTypeEvent, func(event state.GameEvent) error {
+		c.ReceiveGameEvent(event)
+		return nil
+	})
+
+	protocol.Handle(router, util.MsgTypePing, func(ping state.Ping) error {
+		enqueue(outbound, state.Msg{Type: util.MsgTypePong, Payload: state.Pong{
+			Seq:       ping.Seq,
+			SentAt:    ping.SentAt,
+			RepliedAt: int(time.Now().UnixMilli()),
+		}}, c.Fail)
+		return nil
+	})
+
+	protocol.Handle(router, util.MsgTypePong, func(pong state.Pong) error {
+		slog.Debug("pong", "rtt", time.Since(time.UnixMilli(int64(pong.SentAt))))
+		return nil
+	})
+
+	protocol.Handle(router, util.MsgTypeChat, func(chat state.Chat) error {
+		c.ReceiveChat(chat)
+		return nil
+	})
+
+	protocol.Handle(router, util.MsgTypeJoin, func(presence state.Presence) error {
+		slog.Info("player joined", "name", presence.Name)
+		return nil
+	})
+
+	protocol.Handle(router, util.MsgTypeLeave, func(presence state.Presence) error {
+		slog.Info("player left", "name", presence.Name)
+		return nil
+	})
+
+	protocol.Handle(router, util.MsgTypeError, func(protocolErr state.ProtocolError) error {
+		slog.Warn("server rejected a message", "err", protocolErr.Message)
+		return nil
+	})
+
+	return router
+}
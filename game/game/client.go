@@ -0,0 +1,316 @@
+package game
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livingpool/top-down-shooter/game/pkg/bullet"
+	"github.com/livingpool/top-down-shooter/game/pkg/interpolation"
+	"github.com/livingpool/top-down-shooter/game/pkg/player"
+	"github.com/livingpool/top-down-shooter/game/pkg/spawner"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
+	"github.com/livingpool/top-down-shooter/game/util"
+)
+
+// Client-side prediction and server reconciliation, as described in
+// https://www.gabrielgambetta.com/client-side-prediction-server-reconciliation.html
+//
+// The client applies its own inputs as soon as they are sampled, so movement feels instant.
+// When a snapshot arrives, the local player is moved to the server's position,
+// and every input the server hasn't simulated yet is replayed on top of it.
+
+// ReceiveServerUpdate queues a snapshot to be applied on the next Update.
+// It is safe to call from the goroutine reading the connection.
+// If the game falls behind, the oldest snapshot is dropped since a newer one supersedes it.
+func (g *Game) ReceiveServerUpdate(update state.ServerUpdate) {
+	for {
+		select {
+		case g.serverUpdates <- update:
+			return
+		default:
+			select {
+			case <-g.serverUpdates:
+			default:
+			}
+		}
+	}
+}
+
+// ReceiveGameEvent queues an event to be applied on the next Update.
+// It is safe to call from the goroutine reading the connection, and never blocks it,
+// e.g. while ebiten pauses Update because the window lost focus.
+// If the game falls behind, the oldest event is dropped: every event is a phase change so far,
+// and the latest one tells the current phase on its own.
+func (g *Game) ReceiveGameEvent(event state.GameEvent) {
+	for {
+		select {
+		case g.gameEvents <- event:
+			return
+		default:
+			select {
+			case <-g.gameEvents:
+			default:
+			}
+		}
+	}
+}
+
+// UpdateClient applies what the server sent since the last frame,
+// then predicts the outcome of the keys the player is pressing and sends them to the server.
+func (g *Game) UpdateClient(keys util.KeyPress, now time.Time) {
+	for len(g.gameEvents) > 0 {
+		g.applyGameEvent(<-g.gameEvents)
+	}
+	for len(g.serverUpdates) > 0 {
+		update, ok := g.patchServerUpdate(<-g.serverUpdates)
+		if ok {
+			g.applyServerUpdate(update)
+		}
+	}
+	g.interpolate(now)
+
+	// the server cools the player down with every step, whether it sent inputs or not
+	p, exists := g.Players[g.LocalPlayerID]
+	dt := g.frames.Lap(now)
+	if exists {
+		p.Cool(dt)
+	}
+
+	// the server ignores inputs until the round starts, so there's nothing to predict,
+	// and spectators have no player to control
+	if g.Phase != PhaseInProgress || g.LocalPlayerID == uuid.Nil {
+		return
+	}
+	if keys == (util.KeyPress{}) {
+		return
+	}
+
+	g.InputSeq++
+	input := util.ClientUpdate{
+		PlayerId:  g.LocalPlayerID.String(),
+		Keys:      keys,
+		Seq:       g.InputSeq,
+		TimeStamp: int(now.UnixMilli()),
+	}
+
+	// predict the outcome of the input instead of waiting for the server,
+	// the bullet it fires comes with the next snapshot though
+	if exists {
+		p.Move(keys)
+	}
+
+	g.PendingInputs = append(g.PendingInputs, input)
+	if g.SendInput != nil {
+		g.SendInput(input)
+	}
+}
+
+func (g *Game) applyGameEvent(event state.GameEvent) {
+	switch event.Event {
+	case state.EventPhaseChanged:
+		g.Phase = Phase(event.Phase)
+		g.PhaseEndsAt = time.Time{}
+		if event.EndsAt != 0 {
+			g.PhaseEndsAt = time.UnixMilli(int64(event.EndsAt))
+		}
+		slog.Info("game phase changed", "from", event.PrevPhase, "to", event.Phase)
+	default:
+		slog.Warn("unknown game event", "event", event.Event)
+	}
+}
+
+// patchServerUpdate turns a delta into a full snapshot, and acknowledges it
+// so the server can send the next deltas against it.
+// Deltas against a baseline that is no longer in the history are dropped;
+// the server falls back to a full snapshot once the last acknowledged one is too old.
+func (g *Game) patchServerUpdate(update state.ServerUpdate) (state.ServerUpdate, bool) {
+	if update.Baseline != 0 {
+		baseline, exists := g.snapshots.Get(update.Baseline)
+		if !exists {
+			slog.Warn("dropping snapshot with unknown baseline", "seq", update.Seq, "baseline", update.Baseline)
+			return state.ServerUpdate{}, false
+		}
+		update = state.Patch(baseline, update)
+	}
+
+	g.snapshots.Add(update)
+	if g.SendAck != nil {
+		g.SendAck(util.SnapshotAck{Seq: update.Seq})
+	}
+
+	return update, true
+}
+
+// applyServerUpdate replaces the local world with the server's,
+// then reconciles the local player with its unacknowledged inputs.
+// Every other entity is buffered to be interpolated, see interpolate.
+func (g *Game) applyServerUpdate(update state.ServerUpdate) {
+	serverTime := time.UnixMilli(int64(update.TimeStamp))
+	if serverTime.After(g.lastServerTime) {
+		g.lastServerTime = serverTime
+		g.lastServerUpdateAt = g.Clock.Now()
+	}
+	g.Phase = Phase(update.Phase)
+
+	seen := make(map[uuid.UUID]bool, len(update.Players))
+	for _, ps := range update.Players {
+		id, err := uuid.Parse(ps.ID)
+		if err != nil {
+			slog.Error("error parsing player id", "id", ps.ID)
+			continue
+		}
+		seen[id] = true
+
+		p, exists := g.Players[id]
+		if !exists {
+			p = player.NewPlayer(ps.Name)
+			p.ID = id
+			p.Object.X, p.Object.Y, p.Object.Rotation = ps.X, ps.Y, ps.Rotation
+			g.Players[id] = p
+		}
+
+		p.Team = ps.Team
+		p.Health = ps.Health
+		p.Ammo = ps.Ammo
+		p.LastInputSeq = ps.LastInputSeq
+		p.Latency.RTT = time.Duration(ps.RTT) * time.Millisecond
+		p.Latency.Jitter = time.Duration(ps.Jitter) * time.Millisecond
+		p.Latency.ClockOffset = time.Duration(ps.ClockOffset) * time.Millisecond
+
+		if id == g.LocalPlayerID {
+			p.Object.X, p.Object.Y, p.Object.Rotation = ps.X, ps.Y, ps.Rotation
+			g.reconcile(p)
+		} else {
+			g.bufferSample(id, serverTime, ps.X, ps.Y, ps.Rotation)
+		}
+	}
+	for id := range g.Players {
+		if !seen[id] {
+			delete(g.Players, id)
+			delete(g.buffers, id)
+		}
+	}
+
+	bullets := make(map[uuid.UUID]*bullet.Bullet, len(update.Bullets))
+	for _, bs := range update.Bullets {
+		id, err := uuid.Parse(bs.ID)
+		if err != nil {
+			slog.Error("error parsing bullet id", "id", bs.ID)
+			continue
+		}
+
+		b, exists := g.Bullets[id]
+		if !exists {
+			b = bullet.NewBullet(util.Vector{X: bs.X, Y: bs.Y}, bs.Rotation)
+			b.ID = id
+		}
+		g.bufferSample(id, serverTime, bs.X, bs.Y, bs.Rotation)
+		bullets[id] = b
+	}
+	for id := range g.Bullets {
+		if _, exists := bullets[id]; !exists {
+			delete(g.buffers, id)
+		}
+	}
+	g.Bullets = bullets
+
+	zombies := make(map[uuid.UUID]*spawner.Zombie, len(update.Zombies))
+	for _, zs := range update.Zombies {
+		id, err := uuid.Parse(zs.ID)
+		if err != nil {
+			slog.Error("error parsing zombie id", "id", zs.ID)
+			continue
+		}
+
+		z, exists := g.Zombies[id]
+		if !exists {
+			z = spawner.NewZombie()
+			z.ID = id
+			z.Object.X, z.Object.Y, z.Object.Rotation = zs.X, zs.Y, zs.Rotation
+		}
+		g.bufferSample(id, serverTime, zs.X, zs.Y, zs.Rotation)
+		zombies[id] = z
+	}
+	for id := range g.Zombies {
+		if _, exists := zombies[id]; !exists {
+			delete(g.buffers, id)
+		}
+	}
+	g.Zombies = zombies
+}
+
+func (g *Game) bufferSample(id uuid.UUID, t time.Time, x, y, rotation float64) {
+	buf, exists := g.buffers[id]
+	if !exists {
+		buf = interpolation.NewBuffer()
+		g.buffers[id] = buf
+	}
+	buf.Push(interpolation.Sample{Time: t, X: x, Y: y, Rotation: rotation})
+}
+
+// interpolationDelay is how far in the past remote entities are rendered.
+// The more the snapshots' arrival jitters, the more of them are buffered, up to twice util.InterpolationDelay.
+// The server assumes the same delay when rewinding shots, see rewindFor.
+func interpolationDelay(jitter time.Duration) time.Duration {
+	return util.InterpolationDelay + min(2*jitter, util.InterpolationDelay)
+}
+
+// ServerTime converts the client's time to the server's, using the clock offset the server measured.
+func (g *Game) ServerTime(t time.Time) time.Time {
+	if p, exists := g.Players[g.LocalPlayerID]; exists {
+		return t.Add(-p.Latency.ClockOffset)
+	}
+	return t
+}
+
+// interpolate moves every remote entity to where it was interpolationDelay ago in server time.
+// Server time is estimated from the newest snapshot and how long ago it arrived.
+func (g *Game) interpolate(now time.Time) {
+	if g.lastServerTime.IsZero() {
+		return
+	}
+
+	var jitter time.Duration
+	if p, exists := g.Players[g.LocalPlayerID]; exists {
+		jitter = p.Latency.Jitter
+	}
+	renderTime := g.lastServerTime.Add(now.Sub(g.lastServerUpdateAt) - interpolationDelay(jitter))
+
+	for id, p := range g.Players {
+		if id == g.LocalPlayerID {
+			continue
+		}
+		if s, ok := g.buffers[id].At(renderTime); ok {
+			p.Object.X, p.Object.Y, p.Object.Rotation = s.X, s.Y, s.Rotation
+		}
+	}
+	for id, b := range g.Bullets {
+		if s, ok := g.buffers[id].At(renderTime); ok {
+			b.Object.X, b.Object.Y, b.Object.Rotation = s.X, s.Y, s.Rotation
+		}
+	}
+	for id, z := range g.Zombies {
+		if s, ok := g.buffers[id].At(renderTime); ok {
+			z.Object.X, z.Object.Y, z.Object.Rotation = s.X, s.Y, s.Rotation
+		}
+	}
+}
+
+// reconcile drops the inputs the server has acknowledged,
+// and replays the rest on top of the server's position of the local player.
+func (g *Game) reconcile(p *player.Player) {
+	// snapshots don't include the cooldown, the predicted one already accounts for every pending input
+	cooldown := p.ShootCoolDown
+	defer func() { p.ShootCoolDown = cooldown }()
+
+	pending := g.PendingInputs[:0]
+	for _, input := range g.PendingInputs {
+		if input.Seq <= p.LastInputSeq {
+			continue
+		}
+		p.Move(input.Keys)
+		pending = append(pending, input)
+	}
+	g.PendingInputs = pending
+}
@@ -0,0 +1,79 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/livingpool/top-down-shooter/game/pkg/player"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
+	"github.com/livingpool/top-down-shooter/game/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcile(t *testing.T) {
+	g := NewGame(false)
+	p := player.NewPlayer("me")
+	g.LocalPlayerID = p.ID
+	g.Players[p.ID] = p
+
+	step := util.PlayerSpeedPerSecond * util.ClientInputPeriod.Seconds()
+	startX := p.Object.X
+
+	// three inputs predicted locally
+	for seq := 1; seq <= 3; seq++ {
+		keys := util.KeyPress{D: true}
+		p.Move(keys)
+		g.PendingInputs = append(g.PendingInputs, util.ClientUpdate{Keys: keys, Seq: seq})
+	}
+	assert.InDelta(t, startX+3*step, p.Object.X, 0.001)
+
+	// the server has only simulated the first one
+	g.applyServerUpdate(state.ServerUpdate{
+		Players: []state.PlayerState{{
+			ID:           p.ID.String(),
+			X:            startX + step,
+			Y:            p.Object.Y,
+			LastInputSeq: 1,
+		}},
+	})
+
+	assert.Len(t, g.PendingInputs, 2)
+	assert.InDelta(t, startX+3*step, p.Object.X, 0.001)
+
+	// the server corrected our position, e.g. we ran into something
+	g.applyServerUpdate(state.ServerUpdate{
+		Players: []state.PlayerState{{
+			ID:           p.ID.String(),
+			X:            startX,
+			Y:            p.Object.Y,
+			LastInputSeq: 2,
+		}},
+	})
+
+	assert.Len(t, g.PendingInputs, 1)
+	assert.InDelta(t, startX+step, p.Object.X, 0.001)
+}
+
+func TestReceiveGameEventNeverBlocks(t *testing.T) {
+	g := NewGame(false)
+
+	// the window lost focus, so nothing is applied while the phases cycle
+	phases := []Phase{PhaseCountdown, PhaseInProgress, PhaseRoundOver, PhaseWaiting}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 4 * util.ServerUpdateBufferSize {
+			g.ReceiveGameEvent(state.GameEvent{Event: state.EventPhaseChanged, Phase: string(phases[i%len(phases)])})
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ReceiveGameEvent blocked")
+	}
+
+	// the latest phase wins once the game catches up
+	g.UpdateClient(util.KeyPress{}, time.Now())
+	assert.Equal(t, PhaseWaiting, g.Phase)
+}
@@ -5,12 +5,19 @@ import "log"
 // TODO: this obviously needs some fix
 func (g *Game) checkCollisions() {
 	// player collides with bullet
-	for _, player := range g.Players {
-		for _, b := range g.Bullets {
-			if player.Collider().Intersects(b.Collider()) {
+	// bullets are checked against the players' positions as the shooter saw them, see lagcomp.go
+	for id, b := range g.Bullets {
+		seenAt := g.PhysicsLastUpdateTime.Add(-b.Rewind)
+		for _, player := range g.Players {
+			// the shooter can't hit itself, and the dead don't absorb bullets
+			if player.ID == b.OwnerID || player.Health <= 0 {
+				continue
+			}
+			if g.colliderAt(player, seenAt).Intersects(b.Collider()) {
 				log.Println("player collided with bullet!")
-				// g.Bullets = slices.Delete(g.Bullets, j, j+1)
-				// player.Health--
+				delete(g.Bullets, id)
+				player.Health--
+				break
 			}
 		}
 	}
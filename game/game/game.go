@@ -1,74 +1,181 @@
 package game
 
 import (
+	"math"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/livingpool/top-down-shooter/game/pkg/bullet"
+	"github.com/livingpool/top-down-shooter/game/pkg/clock"
+	"github.com/livingpool/top-down-shooter/game/pkg/interpolation"
 	"github.com/livingpool/top-down-shooter/game/pkg/player"
+	"github.com/livingpool/top-down-shooter/game/pkg/spawner"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
 	"github.com/livingpool/top-down-shooter/game/util"
 )
 
 // The main game class. This gets created on both server and client.
 // Server creates one game instance for each game that is hosted,
-// and client creates one for itself to play the game.
+// and client creates one for itself to play the game, which game/client renders.
+// It must not depend on ebiten, so the server can run headless.
 // TODO: set boundaries
-// TODO: upon game creation, client need to construct the Players map
 type Game struct {
 	ID        uuid.UUID
 	DebugMode bool
-	IsServer  bool // store a flag to determine if this instance is a server or client
+	IsServer  bool        // store a flag to determine if this instance is a server or client
+	Clock     clock.Clock // the real one, unless a test replaces it
+
+	Phase          Phase
+	PhaseStartedAt time.Time
+	events         []state.GameEvent // server only, queued for broadcast, see DrainEvents
+
+	PhysicsDelta          int       // in ms
+	PhysicsLastUpdateTime time.Time // when the server last stepped this game
+
+	// server only, for lag compensation
+	RewindWindow time.Duration // how far in the past bullets can be checked against players
+	history      []frame       // colliders of every player at each recent physics step
 
 	// not yet used
-	PhysicsDelta          int
-	PhysicsLastUpdateTime time.Time // in ms
-	LocalTimeElapsed      int       // in seconds
-	LocalDelta            int
-	LocalLastFrameTime    time.Time // in ms
+	LocalTimeElapsed   int // in seconds
+	LocalDelta         int
+	LocalLastFrameTime time.Time // in ms
 
 	Players map[uuid.UUID]*player.Player
 	Bullets map[uuid.UUID]*bullet.Bullet
+	Zombies map[uuid.UUID]*spawner.Zombie
+
+	// client only
+	LocalPlayerID uuid.UUID               // the player controlled by this client
+	InputSeq      int                     // seq of the last input sampled by this client
+	PendingInputs []util.ClientUpdate     // inputs sent to the server that it has not acknowledged yet
+	SendInput     func(util.ClientUpdate) // forwards an input to the server
+	SendAck       func(util.SnapshotAck)  // tells the server the last snapshot that was applied
+	snapshots     *state.History          // the last full snapshots, to patch the deltas against
+	serverUpdates chan state.ServerUpdate // snapshots received from the server, applied on the next Update
+	gameEvents    chan state.GameEvent    // events received from the server, applied on the next Update
+	PhaseEndsAt   time.Time               // when the current phase times out in server time, if it does
+	frames        clock.Stopwatch         // time between client updates, which the local player's cooldown runs with
+
+	// client only, for entity interpolation
+	buffers            map[uuid.UUID]*interpolation.Buffer // recent states of every remote entity
+	lastServerTime     time.Time                           // timestamp of the newest snapshot
+	lastServerUpdateAt time.Time                           // when the newest snapshot was applied
 }
 
 func NewGame(isServer bool) *Game {
-	return &Game{
-		ID:        uuid.New(),
-		DebugMode: true,
-		IsServer:  isServer,
-		Players:   make(map[uuid.UUID]*player.Player),
-		Bullets:   make(map[uuid.UUID]*bullet.Bullet),
+	g := &Game{
+		ID:           uuid.New(),
+		DebugMode:    true,
+		IsServer:     isServer,
+		Clock:        clock.Real{},
+		Phase:        PhaseWaiting,
+		RewindWindow: util.DefaultRewindWindow,
+		Players:      make(map[uuid.UUID]*player.Player),
+		Bullets:      make(map[uuid.UUID]*bullet.Bullet),
+		Zombies:      make(map[uuid.UUID]*spawner.Zombie),
 	}
+
+	if !isServer {
+		g.PendingInputs = make([]util.ClientUpdate, 0)
+		g.serverUpdates = make(chan state.ServerUpdate, util.ServerUpdateBufferSize)
+		g.gameEvents = make(chan state.GameEvent, util.ServerUpdateBufferSize)
+		g.buffers = make(map[uuid.UUID]*interpolation.Buffer)
+		g.snapshots = state.NewHistory()
+	}
+
+	return g
 }
 
-// The physics update loop
-func (g *Game) Update() error {
+// Step advances the game world by dt. It cools down the players' guns, applies every queued player input,
+// moves the bullets, removes those that missed, and checks for collisions.
+// Dead players can't move or shoot; their inputs are acknowledged without being simulated.
+// The server calls this at fixed intervals, so it must not depend on ebiten's game loop.
+func (g *Game) Step(dt time.Duration) {
 	for _, p := range g.Players {
-		p.Update()
+		if p.Health <= 0 {
+			p.SkipInputs()
+			continue
+		}
+		p.Cool(dt)
+		if b := p.Update(); b != nil {
+			b.Rewind = g.rewindFor(b.FiredAt, p.Latency.RTT, p.Latency.Jitter)
+			g.Bullets[b.ID] = b
+		}
 	}
-	for _, b := range g.Bullets {
-		b.Update()
+	for id, b := range g.Bullets {
+		b.Update(dt)
+		if b.Expired() {
+			delete(g.Bullets, id)
+		}
+	}
+	for _, z := range g.Zombies {
+		z.Update()
 	}
 	g.checkCollisions()
-	return nil
+	g.recordHistory()
 }
 
-func (g *Game) Draw(screen *ebiten.Image) {
-	for _, p := range g.Players {
-		p.Draw(screen, g.DebugMode)
+// FreeSpawnPosition returns the spawn position closest to the center of the screen
+// that is at least util.PlayerSpawnSpacing away from every player.
+// Candidates are tried on rings around the center, 8 per ring.
+func (g *Game) FreeSpawnPosition() util.Vector {
+	center := util.Vector{X: util.InitialPlayerX, Y: util.InitialPlayerY}
+	if g.isFree(center) {
+		return center
 	}
-	for _, b := range g.Bullets {
-		b.Draw(screen, g.DebugMode)
+
+	for ring := 1; ; ring++ {
+		r := float64(ring) * util.PlayerSpawnSpacing
+		for i := range 8 {
+			angle := float64(i) * math.Pi / 4
+			pos := util.Vector{X: center.X + math.Cos(angle)*r, Y: center.Y + math.Sin(angle)*r}
+			if g.isFree(pos) {
+				return pos
+			}
+		}
+	}
+}
+
+func (g *Game) isFree(pos util.Vector) bool {
+	for _, p := range g.Players {
+		if math.Hypot(p.Object.X-pos.X, p.Object.Y-pos.Y) < util.PlayerSpawnSpacing {
+			return false
+		}
 	}
+	return true
 }
 
-func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return util.ScreenWidth, util.ScreenHeight
+// SmallestTeam returns the team with the fewest players, the first one on ties.
+func (g *Game) SmallestTeam() int {
+	sizes := make([]int, util.TeamCount+1)
+	for _, p := range g.Players {
+		if p.Team >= 1 && p.Team <= util.TeamCount {
+			sizes[p.Team]++
+		}
+	}
+
+	team := 1
+	for t := 2; t <= util.TeamCount; t++ {
+		if sizes[t] < sizes[team] {
+			team = t
+		}
+	}
+	return team
 }
 
-// TODO: randomize players' spawn positions; at both initial spawn and reset
+// Reset gets the game ready for a new round:
+// every player is respawned with full health and ammo, and the world is cleared.
 func (g *Game) Reset() {
-	for i := range g.Players {
-		g.Players[i] = player.NewPlayer("todo")
+	g.Bullets = make(map[uuid.UUID]*bullet.Bullet)
+	g.Zombies = make(map[uuid.UUID]*spawner.Zombie)
+	g.history = nil
+
+	spawned := g.Players
+	g.Players = make(map[uuid.UUID]*player.Player, len(spawned))
+	for id, p := range spawned {
+		p.Reset()
+		p.Object.Vector = g.FreeSpawnPosition()
+		g.Players[id] = p
 	}
 }
@@ -0,0 +1,83 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/livingpool/top-down-shooter/game/pkg/bullet"
+	"github.com/livingpool/top-down-shooter/game/pkg/player"
+	"github.com/livingpool/top-down-shooter/game/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepRemovesMissedBullets(t *testing.T) {
+	g := NewGame(true)
+	center := util.Vector{X: util.ScreenWidth / 2, Y: util.ScreenHeight / 2}
+
+	flying := bullet.NewBullet(center, 0)
+	old := bullet.NewBullet(center, 0)
+	old.Age = util.BulletLifetime
+	gone := bullet.NewBullet(util.Vector{X: -util.ScreenWidth, Y: center.Y}, 0)
+	for _, b := range []*bullet.Bullet{flying, old, gone} {
+		g.Bullets[b.ID] = b
+	}
+
+	g.Step(util.ServerPhysicsPeriod)
+	assert.Contains(t, g.Bullets, flying.ID)
+	assert.NotContains(t, g.Bullets, old.ID, "flew for too long")
+	assert.NotContains(t, g.Bullets, gone.ID, "left the world")
+
+	// every bullet eventually leaves the world
+	for range util.BulletLifetime / util.ServerPhysicsPeriod {
+		g.Step(util.ServerPhysicsPeriod)
+	}
+	assert.Empty(t, g.Bullets)
+}
+
+func TestStepCoolsDownIdlePlayers(t *testing.T) {
+	g := NewGame(true)
+	p := player.NewPlayer("tim")
+	g.Players[p.ID] = p
+
+	// no inputs are sent while the player stands still, its gun cools down anyway
+	for range util.PlayerShootCoolDown / util.ServerPhysicsPeriod {
+		g.Step(util.ServerPhysicsPeriod)
+	}
+	assert.NotZero(t, p.ShootCoolDown)
+	g.Step(util.ServerPhysicsPeriod)
+	assert.Zero(t, p.ShootCoolDown)
+}
+
+func TestDeadPlayersNeitherTakeHitsNorShoot(t *testing.T) {
+	g := NewGame(true)
+	dead, alive, shooter := player.NewPlayer("dead"), player.NewPlayer("alive"), player.NewPlayer("shooter")
+	for _, p := range []*player.Player{dead, alive, shooter} {
+		g.Players[p.ID] = p
+	}
+	dead.Health = 0
+	dead.ShootCoolDown = 0
+	alive.Object.Vector = util.Vector{X: 100, Y: 100}
+	shooter.Object.Vector = util.Vector{X: 600, Y: 500}
+
+	// the dead player tries to move and shoot
+	start := dead.Object.Vector
+	dead.ClientUpdates.Push(util.ClientUpdate{Seq: 1, Keys: util.KeyPress{D: true, Space: true}})
+
+	// and is shot at, just like the one still alive
+	bullets := make(map[*player.Player]*bullet.Bullet)
+	for _, target := range []*player.Player{dead, alive} {
+		b := bullet.NewBullet(target.Object.Vector, 0)
+		b.OwnerID = shooter.ID
+		g.Bullets[b.ID] = b
+		bullets[target] = b
+	}
+
+	g.Step(util.ServerPhysicsPeriod)
+
+	assert.Equal(t, start, dead.Object.Vector, "didn't move")
+	assert.Equal(t, 1, dead.LastInputSeq, "the input is acknowledged anyway")
+	assert.Zero(t, dead.Health)
+	assert.Contains(t, g.Bullets, bullets[dead].ID, "the bullet flies through")
+	assert.Equal(t, util.InitialPlayerHealth-1, alive.Health)
+	assert.NotContains(t, g.Bullets, bullets[alive].ID)
+	assert.Len(t, g.Bullets, 1, "the dead player didn't fire")
+}
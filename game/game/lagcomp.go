@@ -0,0 +1,72 @@
+package game
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livingpool/top-down-shooter/game/pkg/player"
+	"github.com/livingpool/top-down-shooter/game/util"
+)
+
+// Lag compensation, as described in
+// https://www.gabrielgambetta.com/lag-compensation.html
+//
+// A shooter sees the other players where they were a round trip plus the interpolation delay ago,
+// so that's where they aim. The server records every player's collider at each physics step,
+// and checks a bullet against the colliders at the time its shooter fired it.
+// The rewind is bounded by RewindWindow, so a laggy client can't shoot too far into the past.
+
+// frame is the colliders of every player at a physics step.
+type frame struct {
+	time      time.Time
+	colliders map[uuid.UUID]util.Rect
+}
+
+// recordHistory saves the current colliders of every player,
+// and drops the frames that have fallen out of the rewind window.
+func (g *Game) recordHistory() {
+	f := frame{
+		time:      g.PhysicsLastUpdateTime,
+		colliders: make(map[uuid.UUID]util.Rect, len(g.Players)),
+	}
+	for id, p := range g.Players {
+		f.colliders[id] = p.Collider()
+	}
+	g.history = append(g.history, f)
+
+	oldest := g.PhysicsLastUpdateTime.Add(-g.RewindWindow)
+	i := 0
+	for i < len(g.history)-1 && g.history[i].time.Before(oldest) {
+		i++
+	}
+	g.history = g.history[i:]
+}
+
+// rewindFor returns how far in the past a shot fired at firedAt (in server time) should be checked,
+// given the round trip time and jitter of the shooter's connection.
+// The time since firedAt covers the trip of the shot to the server, and half the round trip
+// the one of the snapshot the shooter aimed at. Its interpolation delay depends on the jitter.
+// Shots without a timestamp are not compensated.
+func (g *Game) rewindFor(firedAt time.Time, rtt, jitter time.Duration) time.Duration {
+	if firedAt.IsZero() {
+		return 0
+	}
+
+	rewind := g.PhysicsLastUpdateTime.Sub(firedAt) + rtt/2 + interpolationDelay(jitter)
+	return min(max(rewind, 0), g.RewindWindow)
+}
+
+// colliderAt returns the player's collider at time t, i.e., the latest frame recorded at or before t.
+// The current collider is returned if there is no history, e.g. the player has just joined.
+func (g *Game) colliderAt(p *player.Player, t time.Time) util.Rect {
+	for i := len(g.history) - 1; i >= 0; i-- {
+		if g.history[i].time.After(t) {
+			continue
+		}
+		if collider, exists := g.history[i].colliders[p.ID]; exists {
+			return collider
+		}
+		break
+	}
+	return p.Collider()
+}
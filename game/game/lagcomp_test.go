@@ -0,0 +1,51 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/livingpool/top-down-shooter/game/pkg/player"
+	"github.com/livingpool/top-down-shooter/game/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewindFor(t *testing.T) {
+	g := NewGame(true)
+	g.PhysicsLastUpdateTime = time.UnixMilli(10_000)
+
+	// no timestamp, no compensation
+	assert.Equal(t, time.Duration(0), g.rewindFor(time.Time{}, 100*time.Millisecond, 0))
+
+	// 50ms for the shot to get here, 50ms for the snapshot the shooter aimed at to get there,
+	// plus what the shooter's interpolation hides
+	firedAt := g.PhysicsLastUpdateTime.Add(-50 * time.Millisecond)
+	assert.Equal(t, 100*time.Millisecond+util.InterpolationDelay, g.rewindFor(firedAt, 100*time.Millisecond, 0))
+
+	// a jittery shooter renders further in the past
+	g.RewindWindow = time.Second
+	assert.Equal(t, 120*time.Millisecond+util.InterpolationDelay, g.rewindFor(firedAt, 100*time.Millisecond, 10*time.Millisecond))
+
+	// never further than the window
+	firedAt = g.PhysicsLastUpdateTime.Add(-2 * time.Second)
+	assert.Equal(t, g.RewindWindow, g.rewindFor(firedAt, 100*time.Millisecond, 0))
+}
+
+func TestColliderAt(t *testing.T) {
+	g := NewGame(true)
+	p := player.NewPlayer("target")
+	g.Players[p.ID] = p
+
+	start := time.UnixMilli(10_000)
+	for i := range 5 {
+		g.PhysicsLastUpdateTime = start.Add(time.Duration(i) * util.ServerPhysicsPeriod)
+		p.Object.X = float64(i * 10)
+		g.recordHistory()
+	}
+
+	// in between two steps, we get the earlier one
+	seenAt := start.Add(util.ServerPhysicsPeriod + util.ServerPhysicsPeriod/2)
+	assert.Equal(t, 10.0, g.colliderAt(p, seenAt).X)
+
+	// before any recorded step, we get the current one
+	assert.Equal(t, 40.0, g.colliderAt(p, start.Add(-time.Second)).X)
+}
@@ -0,0 +1,115 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
+	"github.com/livingpool/top-down-shooter/game/util"
+)
+
+// Phase is where a hosted game is in its lifecycle.
+//
+//	waiting -> countdown -> in_progress -> round_over -> countdown -> ...
+//
+// Any phase can move to closed, after which the game is gone for good.
+// A countdown goes back to waiting if every player leaves,
+// and a round that is over does too if nobody is left to play the next one.
+type Phase string
+
+const (
+	PhaseWaiting    Phase = "waiting"     // waiting for players to join, until someone starts the game
+	PhaseCountdown  Phase = "countdown"   // players are about to be let loose
+	PhaseInProgress Phase = "in_progress" // the only phase in which the world is simulated
+	PhaseRoundOver  Phase = "round_over"  // showing the results before the next round
+	PhaseClosed     Phase = "closed"
+)
+
+var transitions = map[Phase][]Phase{
+	PhaseWaiting:    {PhaseCountdown, PhaseClosed},
+	PhaseCountdown:  {PhaseInProgress, PhaseWaiting, PhaseClosed},
+	PhaseInProgress: {PhaseRoundOver, PhaseClosed},
+	PhaseRoundOver:  {PhaseCountdown, PhaseWaiting, PhaseClosed},
+	PhaseClosed:     {},
+}
+
+// phaseDurations are how long the timed phases last before moving on by themselves.
+var phaseDurations = map[Phase]time.Duration{
+	PhaseCountdown:  util.CountdownDuration,
+	PhaseInProgress: util.RoundDuration,
+	PhaseRoundOver:  util.RoundOverDuration,
+}
+
+// SetPhase moves the game to the next phase and queues an event to tell the players.
+// It returns an error if the transition is not legal.
+func (g *Game) SetPhase(next Phase, now time.Time) error {
+	if !slices.Contains(transitions[g.Phase], next) {
+		return fmt.Errorf("illegal phase transition: %v -> %v", g.Phase, next)
+	}
+
+	prev := g.Phase
+	g.Phase = next
+	g.PhaseStartedAt = now
+
+	event := state.GameEvent{
+		Event:     state.EventPhaseChanged,
+		GameId:    g.ID.String(),
+		Phase:     string(next),
+		PrevPhase: string(prev),
+		TimeStamp: int(now.UnixMilli()),
+	}
+	if d, timed := phaseDurations[next]; timed {
+		event.EndsAt = int(now.Add(d).UnixMilli())
+	}
+	g.events = append(g.events, event)
+
+	return nil
+}
+
+// UpdatePhase makes the transitions that don't need anyone to ask for them:
+// timers running out, a round being decided, or every player leaving.
+func (g *Game) UpdatePhase(now time.Time) {
+	elapsed := now.Sub(g.PhaseStartedAt)
+
+	switch g.Phase {
+	case PhaseCountdown:
+		if len(g.Players) == 0 {
+			g.SetPhase(PhaseWaiting, now)
+		} else if elapsed >= phaseDurations[PhaseCountdown] {
+			g.SetPhase(PhaseInProgress, now)
+		}
+	case PhaseInProgress:
+		if elapsed >= phaseDurations[PhaseInProgress] || g.isRoundDecided() {
+			g.SetPhase(PhaseRoundOver, now)
+		}
+	case PhaseRoundOver:
+		if elapsed >= phaseDurations[PhaseRoundOver] {
+			g.Reset()
+			if len(g.Players) == 0 {
+				g.SetPhase(PhaseWaiting, now)
+			} else {
+				g.SetPhase(PhaseCountdown, now)
+			}
+		}
+	}
+}
+
+// isRoundDecided reports whether the round can't go on:
+// everybody is dead, or there is a single survivor of a multiplayer round.
+func (g *Game) isRoundDecided() bool {
+	alive := 0
+	for _, p := range g.Players {
+		if p.Health > 0 {
+			alive++
+		}
+	}
+	return alive == 0 || (len(g.Players) > 1 && alive == 1)
+}
+
+// DrainEvents returns the events queued since the last call, to be broadcast by the server.
+func (g *Game) DrainEvents() []state.GameEvent {
+	events := g.events
+	g.events = nil
+	return events
+}
@@ -0,0 +1,48 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/livingpool/top-down-shooter/game/pkg/player"
+	"github.com/livingpool/top-down-shooter/game/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPhaseTransitions(t *testing.T) {
+	g := NewGame(true)
+	now := time.UnixMilli(10_000)
+
+	// can't skip the countdown
+	assert.Error(t, g.SetPhase(PhaseInProgress, now))
+	assert.Equal(t, PhaseWaiting, g.Phase)
+
+	p1, p2 := player.NewPlayer("tim"), player.NewPlayer("steven")
+	g.Players[p1.ID] = p1
+	g.Players[p2.ID] = p2
+
+	assert.NoError(t, g.SetPhase(PhaseCountdown, now))
+
+	now = now.Add(util.CountdownDuration)
+	g.UpdatePhase(now)
+	assert.Equal(t, PhaseInProgress, g.Phase)
+
+	// a single survivor ends the round
+	p2.Health = 0
+	g.UpdatePhase(now)
+	assert.Equal(t, PhaseRoundOver, g.Phase)
+
+	// everybody is back for the next round
+	now = now.Add(util.RoundOverDuration)
+	g.UpdatePhase(now)
+	assert.Equal(t, PhaseCountdown, g.Phase)
+	assert.Equal(t, util.InitialPlayerHealth, p2.Health)
+
+	events := g.DrainEvents()
+	assert.Len(t, events, 4)
+	assert.Equal(t, string(PhaseRoundOver), events[3].PrevPhase)
+	assert.Empty(t, g.DrainEvents())
+
+	assert.NoError(t, g.SetPhase(PhaseClosed, now))
+	assert.Error(t, g.SetPhase(PhaseWaiting, now))
+}
@@ -0,0 +1,56 @@
+package game
+
+import (
+	"time"
+
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
+)
+
+// Snapshot captures the current state of every entity in the game, to be sent to the clients.
+func (g *Game) Snapshot(now time.Time) state.ServerUpdate {
+	update := state.ServerUpdate{
+		GameId:    g.ID.String(),
+		TimeStamp: int(now.UnixMilli()),
+		Phase:     string(g.Phase),
+		Players:   make([]state.PlayerState, 0, len(g.Players)),
+		Bullets:   make([]state.BulletState, 0, len(g.Bullets)),
+		Zombies:   make([]state.ZombieState, 0, len(g.Zombies)),
+	}
+
+	for _, p := range g.Players {
+		update.Players = append(update.Players, state.PlayerState{
+			ID:           p.ID.String(),
+			Name:         p.Name,
+			Team:         p.Team,
+			X:            p.Object.X,
+			Y:            p.Object.Y,
+			Rotation:     p.Object.Rotation,
+			Health:       p.Health,
+			Ammo:         p.Ammo,
+			LastInputSeq: p.LastInputSeq,
+			RTT:          int(p.Latency.RTT.Milliseconds()),
+			Jitter:       int(p.Latency.Jitter.Milliseconds()),
+			ClockOffset:  int(p.Latency.ClockOffset.Milliseconds()),
+		})
+	}
+
+	for _, b := range g.Bullets {
+		update.Bullets = append(update.Bullets, state.BulletState{
+			ID:       b.ID.String(),
+			X:        b.Object.X,
+			Y:        b.Object.Y,
+			Rotation: b.Object.Rotation,
+		})
+	}
+
+	for _, z := range g.Zombies {
+		update.Zombies = append(update.Zombies, state.ZombieState{
+			ID:       z.ID.String(),
+			X:        z.Object.X,
+			Y:        z.Object.Y,
+			Rotation: z.Object.Rotation,
+		})
+	}
+
+	return update
+}
@@ -1,59 +1,51 @@
 package bullet
 
 import (
-	"math"
+	"time"
 
 	"github.com/google/uuid"
-	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/livingpool/top-down-shooter/game/assets"
+	"github.com/livingpool/top-down-shooter/game/pkg/sim"
 	"github.com/livingpool/top-down-shooter/game/util"
 )
 
 type Bullet struct {
-	ID     uuid.UUID
-	Object util.GameObject
+	ID      uuid.UUID
+	Object  util.GameObject
+	OwnerID uuid.UUID     // the player who fired it
+	FiredAt time.Time     // when the shooter fired it, converted to server time
+	Rewind  time.Duration // how far in the past the shooter saw the world, for lag compensation
+	Age     time.Duration // how long it has been flying
 }
 
 func NewBullet(pos util.Vector, rotation float64) *Bullet {
-	sprite := assets.Bullet
-
 	return &Bullet{
 		ID: uuid.New(),
 		Object: util.GameObject{
 			Vector:   pos,
 			Rotation: rotation,
-			Sprite:   sprite,
 		},
 	}
 }
 
-func (b *Bullet) Update() {
-	speed := util.BulletSpeedPerSecond / float64(ebiten.TPS())
-
-	b.Object.Vector.X += math.Sin(b.Object.Rotation) * speed
-	b.Object.Vector.Y += math.Cos(b.Object.Rotation) * -speed
+// Update moves the bullet along its rotation for a duration of dt.
+func (b *Bullet) Update(dt time.Duration) {
+	s := sim.Bullet{Position: b.Object.Vector, Rotation: b.Object.Rotation}
+	s.Step(dt)
+	b.Object.Vector = s.Position
+	b.Age += dt
 }
 
-func (b *Bullet) Draw(screen *ebiten.Image, debugMode bool) {
-	op := b.Object.CenterAndRotateImage()
-
-	op.GeoM.Scale(0.5, 0.5)
-	op.GeoM.Translate(b.Object.Vector.X, b.Object.Vector.Y)
-
-	screen.DrawImage(b.Object.Sprite, op)
-
-	if debugMode {
-		b.Object.DrawDebugCircle(screen, 4, "")
-	}
+// Expired reports whether the bullet has left the world, or has been flying for longer than util.BulletLifetime.
+func (b *Bullet) Expired() bool {
+	world := util.NewRect(0, 0, util.ScreenWidth, util.ScreenHeight)
+	return b.Age > util.BulletLifetime || !b.Collider().Intersects(world)
 }
 
 func (b *Bullet) Collider() util.Rect {
-	bounds := b.Object.Sprite.Bounds()
-
 	return util.NewRect(
 		b.Object.Vector.X,
 		b.Object.Vector.Y,
-		float64(bounds.Dx()),
-		float64(bounds.Dy()),
+		util.BulletWidth,
+		util.BulletHeight,
 	)
 }
@@ -0,0 +1,88 @@
+// Package clock is how the games tell the time, so they can run headless and be tested
+// without waiting for the wall clock or depending on ebiten's tick rate.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// Real is the wall clock.
+type Real struct{}
+
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// Manual is a clock that only moves when told to, for tests.
+// It is safe for concurrent use.
+type Manual struct {
+	mutex *sync.Mutex
+	now   time.Time
+}
+
+func NewManual(now time.Time) *Manual {
+	return &Manual{
+		mutex: &sync.Mutex{},
+		now:   now,
+	}
+}
+
+func (m *Manual) Now() time.Time {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.now
+}
+
+// Advance moves the clock forward by d.
+func (m *Manual) Advance(d time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.now = m.now.Add(d)
+}
+
+// Stopwatch measures the time elapsed between frames.
+// The zero value is ready to use.
+type Stopwatch struct {
+	last time.Time
+}
+
+// Lap returns the time elapsed since the previous lap, or zero on the first one.
+func (s *Stopwatch) Lap(now time.Time) time.Duration {
+	var elapsed time.Duration
+	if !s.last.IsZero() {
+		elapsed = max(now.Sub(s.last), 0)
+	}
+	s.last = now
+	return elapsed
+}
+
+// Timer is ready once it has been updated for its duration.
+type Timer struct {
+	duration time.Duration
+	elapsed  time.Duration
+}
+
+func NewTimer(d time.Duration) *Timer {
+	return &Timer{
+		duration: d,
+	}
+}
+
+// Update advances the timer by dt.
+func (t *Timer) Update(dt time.Duration) {
+	t.elapsed = min(t.elapsed+dt, t.duration)
+}
+
+func (t *Timer) IsReady() bool {
+	return t.elapsed >= t.duration
+}
+
+func (t *Timer) Reset() {
+	t.elapsed = 0
+}
@@ -0,0 +1,34 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStopwatch(t *testing.T) {
+	c := NewManual(time.Now())
+	var s Stopwatch
+
+	assert.Zero(t, s.Lap(c.Now()))
+
+	c.Advance(20 * time.Millisecond)
+	assert.Equal(t, 20*time.Millisecond, s.Lap(c.Now()))
+	assert.Zero(t, s.Lap(c.Now()))
+}
+
+func TestTimer(t *testing.T) {
+	timer := NewTimer(time.Second)
+	assert.False(t, timer.IsReady())
+
+	timer.Update(600 * time.Millisecond)
+	assert.False(t, timer.IsReady())
+	timer.Update(600 * time.Millisecond)
+	assert.True(t, timer.IsReady())
+
+	timer.Reset()
+	assert.False(t, timer.IsReady())
+	timer.Update(time.Second)
+	assert.True(t, timer.IsReady())
+}
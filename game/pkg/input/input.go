@@ -0,0 +1,82 @@
+package input
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/livingpool/top-down-shooter/game/util"
+)
+
+// Queue is a player's inputs waiting to be simulated, in order of Seq.
+// Inputs are pushed by the goroutine reading the player's connection,
+// and drained by the one simulating the game, so it is safe for concurrent use.
+type Queue struct {
+	mutex   *sync.Mutex
+	inputs  []util.ClientUpdate
+	size    int
+	lastSeq int // seq of the last drained input; older ones are duplicates
+}
+
+func NewQueue(size int) *Queue {
+	return &Queue{
+		mutex:  &sync.Mutex{},
+		inputs: make([]util.ClientUpdate, 0, size),
+		size:   size,
+	}
+}
+
+// Push queues an input. It returns false if the input was dropped,
+// either because it was already queued or drained, or because the queue is full.
+func (q *Queue) Push(input util.ClientUpdate) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if input.Seq <= q.lastSeq || len(q.inputs) == q.size {
+		return false
+	}
+
+	i, found := slices.BinarySearchFunc(q.inputs, input.Seq, func(queued util.ClientUpdate, seq int) int {
+		return queued.Seq - seq
+	})
+	if found {
+		return false
+	}
+	q.inputs = slices.Insert(q.inputs, i, input)
+
+	return true
+}
+
+// Drain takes every queued input, in order of Seq.
+// Inputs with a seq up to the last one drained are rejected from then on.
+func (q *Queue) Drain() []util.ClientUpdate {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	inputs := make([]util.ClientUpdate, len(q.inputs))
+	copy(inputs, q.inputs)
+	q.inputs = q.inputs[:0]
+
+	if len(inputs) > 0 {
+		q.lastSeq = inputs[len(inputs)-1].Seq
+	}
+
+	return inputs
+}
+
+// Reset drops every queued input and forgets the last one drained,
+// e.g. when the player reconnects and its client counts inputs from the start again.
+func (q *Queue) Reset() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.inputs = q.inputs[:0]
+	q.lastSeq = 0
+}
+
+// Len returns the number of queued inputs.
+func (q *Queue) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return len(q.inputs)
+}
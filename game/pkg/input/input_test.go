@@ -0,0 +1,64 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/livingpool/top-down-shooter/game/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func seqs(inputs []util.ClientUpdate) []int {
+	s := make([]int, len(inputs))
+	for i, input := range inputs {
+		s[i] = input.Seq
+	}
+	return s
+}
+
+func TestQueue(t *testing.T) {
+	q := NewQueue(3)
+
+	assert.True(t, q.Push(util.ClientUpdate{Seq: 2}))
+	assert.True(t, q.Push(util.ClientUpdate{Seq: 1}))
+	assert.False(t, q.Push(util.ClientUpdate{Seq: 2}), "duplicates are dropped")
+	assert.True(t, q.Push(util.ClientUpdate{Seq: 4}))
+	assert.False(t, q.Push(util.ClientUpdate{Seq: 5}), "the queue is full")
+	assert.Equal(t, 3, q.Len())
+
+	assert.Equal(t, []int{1, 2, 4}, seqs(q.Drain()), "inputs are drained in order")
+	assert.Equal(t, 0, q.Len())
+
+	assert.False(t, q.Push(util.ClientUpdate{Seq: 3}), "inputs older than the drained ones are dropped")
+	assert.True(t, q.Push(util.ClientUpdate{Seq: 5}))
+	assert.Equal(t, []int{5}, seqs(q.Drain()))
+
+	assert.True(t, q.Push(util.ClientUpdate{Seq: 6}))
+	q.Reset()
+	assert.Equal(t, 0, q.Len(), "queued inputs are dropped")
+	assert.True(t, q.Push(util.ClientUpdate{Seq: 1}), "inputs are counted from the start again")
+}
+
+func TestQueueIsSafeForConcurrentUse(t *testing.T) {
+	q := NewQueue(util.InputQueueSize)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for seq := 1; seq <= 1000; seq++ {
+			q.Push(util.ClientUpdate{Seq: seq})
+		}
+	}()
+
+	drained := make([]int, 0)
+	for pushing := true; pushing; {
+		select {
+		case <-done:
+			pushing = false
+		default:
+		}
+		drained = append(drained, seqs(q.Drain())...)
+	}
+
+	assert.NotEmpty(t, drained)
+	assert.IsIncreasing(t, drained)
+}
@@ -0,0 +1,83 @@
+package interpolation
+
+import (
+	"math"
+	"time"
+)
+
+// Entity interpolation, as described in
+// https://www.gabrielgambetta.com/entity-interpolation.html
+//
+// Remote entities only move when a snapshot arrives, so drawing them at the latest position looks jittery.
+// Instead, we keep their recent positions and render them slightly in the past, between two known positions.
+
+// maxSamples bounds the buffer in case At is never called, e.g. when the window is minimized.
+const maxSamples = 32
+
+// Sample is the state of an entity at a point in server time.
+type Sample struct {
+	Time     time.Time
+	X        float64
+	Y        float64
+	Rotation float64
+}
+
+// Buffer keeps the recent samples of a single entity, ordered by time.
+type Buffer struct {
+	samples []Sample
+}
+
+func NewBuffer() *Buffer {
+	return &Buffer{samples: make([]Sample, 0, maxSamples)}
+}
+
+// Push appends a sample. Samples that are not newer than the last one are ignored,
+// since snapshots may arrive out of order.
+func (b *Buffer) Push(s Sample) {
+	if n := len(b.samples); n > 0 && !s.Time.After(b.samples[n-1].Time) {
+		return
+	}
+	if len(b.samples) == maxSamples {
+		b.samples = append(b.samples[:0], b.samples[1:]...)
+	}
+	b.samples = append(b.samples, s)
+}
+
+// At returns the entity's interpolated state at time t, and false if the buffer is empty.
+// If t is outside of the buffered samples, the closest sample is returned as is; we never extrapolate.
+// Samples older than the pair around t are no longer needed and get dropped.
+func (b *Buffer) At(t time.Time) (Sample, bool) {
+	n := len(b.samples)
+	if n == 0 {
+		return Sample{}, false
+	}
+	if !t.After(b.samples[0].Time) {
+		return b.samples[0], true
+	}
+	if !t.Before(b.samples[n-1].Time) {
+		return b.samples[n-1], true
+	}
+
+	// find the pair of samples around t
+	i := 0
+	for !t.Before(b.samples[i+1].Time) {
+		i++
+	}
+	from, to := b.samples[i], b.samples[i+1]
+	b.samples = append(b.samples[:0], b.samples[i:]...)
+
+	frac := float64(t.Sub(from.Time)) / float64(to.Time.Sub(from.Time))
+
+	return Sample{
+		Time:     t,
+		X:        from.X + (to.X-from.X)*frac,
+		Y:        from.Y + (to.Y-from.Y)*frac,
+		Rotation: lerpAngle(from.Rotation, to.Rotation, frac),
+	}, true
+}
+
+// lerpAngle interpolates between two angles along the shortest way around the circle.
+func lerpAngle(from, to, frac float64) float64 {
+	diff := math.Remainder(to-from, 2*math.Pi)
+	return from + diff*frac
+}
@@ -0,0 +1,42 @@
+package interpolation
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBufferAt(t *testing.T) {
+	start := time.UnixMilli(1000)
+	b := NewBuffer()
+
+	_, ok := b.At(start)
+	assert.False(t, ok)
+
+	b.Push(Sample{Time: start, X: 0, Y: 0})
+	b.Push(Sample{Time: start.Add(100 * time.Millisecond), X: 10, Y: 20})
+	b.Push(Sample{Time: start.Add(50 * time.Millisecond), X: 99, Y: 99}) // out of order, ignored
+
+	// before the first sample
+	s, ok := b.At(start.Add(-time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, 0.0, s.X)
+
+	// halfway
+	s, _ = b.At(start.Add(50 * time.Millisecond))
+	assert.InDelta(t, 5, s.X, 0.001)
+	assert.InDelta(t, 10, s.Y, 0.001)
+
+	// after the last sample, we don't extrapolate
+	s, _ = b.At(start.Add(time.Second))
+	assert.Equal(t, 10.0, s.X)
+}
+
+func TestLerpAngle(t *testing.T) {
+	// going from just below pi to just above -pi should take the short way around
+	from, to := math.Pi-0.1, -math.Pi+0.1
+	mid := lerpAngle(from, to, 0.5)
+	assert.InDelta(t, math.Pi, mid, 0.001)
+}
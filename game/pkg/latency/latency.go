@@ -0,0 +1,37 @@
+package latency
+
+import "time"
+
+// Estimator measures the latency of a connection and the offset between the clocks at both ends,
+// from ping/pong exchanges. Samples are smoothed like TCP's round-trip time estimate (RFC 6298),
+// so a single slow pong doesn't throw the estimate off.
+type Estimator struct {
+	RTT         time.Duration // smoothed round-trip time
+	Jitter      time.Duration // smoothed deviation of the round-trip time
+	ClockOffset time.Duration // how far the other end's clock is ahead of ours
+	Samples     int           // number of pongs measured so far
+}
+
+// Sample adds a measurement: sentAt and receivedAt are when the ping was sent and its pong received in our clock,
+// and repliedAt is when the pong was sent in the other end's clock.
+// The pong is assumed to have been sent halfway through the round trip.
+func (e *Estimator) Sample(sentAt, repliedAt, receivedAt time.Time) {
+	rtt := max(receivedAt.Sub(sentAt), 0)
+	offset := repliedAt.Sub(sentAt.Add(rtt / 2))
+
+	if e.Samples == 0 {
+		e.RTT = rtt
+		e.Jitter = rtt / 2
+		e.ClockOffset = offset
+	} else {
+		e.Jitter = (3*e.Jitter + (e.RTT - rtt).Abs()) / 4
+		e.RTT = (7*e.RTT + rtt) / 8
+		e.ClockOffset = (7*e.ClockOffset + offset) / 8
+	}
+	e.Samples++
+}
+
+// LocalTime converts a time in the other end's clock to ours.
+func (e *Estimator) LocalTime(t time.Time) time.Time {
+	return t.Add(-e.ClockOffset)
+}
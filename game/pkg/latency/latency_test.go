@@ -0,0 +1,31 @@
+package latency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimator(t *testing.T) {
+	var e Estimator
+	start := time.UnixMilli(1_700_000_000_000)
+
+	// the other end is 5s ahead, and every round trip takes 100ms
+	for i := range 10 {
+		sentAt := start.Add(time.Duration(i) * time.Second)
+		e.Sample(sentAt, sentAt.Add(50*time.Millisecond+5*time.Second), sentAt.Add(100*time.Millisecond))
+	}
+	assert.Equal(t, 10, e.Samples)
+	assert.Equal(t, 100*time.Millisecond, e.RTT)
+	assert.Equal(t, 5*time.Second, e.ClockOffset)
+	assert.Less(t, e.Jitter, 10*time.Millisecond)
+
+	// a single slow pong only nudges the estimate
+	sentAt := start.Add(time.Minute)
+	e.Sample(sentAt, sentAt.Add(5*time.Second+400*time.Millisecond), sentAt.Add(800*time.Millisecond))
+	assert.Less(t, e.RTT, 200*time.Millisecond)
+	assert.Greater(t, e.Jitter, 100*time.Millisecond)
+
+	assert.Equal(t, start, e.LocalTime(start.Add(e.ClockOffset)))
+}
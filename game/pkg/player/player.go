@@ -1,33 +1,36 @@
 package player
 
 import (
-	"math"
+	"time"
 
 	"github.com/coder/websocket"
 	"github.com/google/uuid"
-	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/livingpool/top-down-shooter/game/assets"
 	"github.com/livingpool/top-down-shooter/game/pkg/bullet"
+	"github.com/livingpool/top-down-shooter/game/pkg/input"
+	"github.com/livingpool/top-down-shooter/game/pkg/latency"
+	"github.com/livingpool/top-down-shooter/game/pkg/sim"
 	"github.com/livingpool/top-down-shooter/game/util"
 )
 
 type Player struct {
 	ID            uuid.UUID
 	Name          string
+	Team          int // 1 or 2, assigned by the server when the player is added to a game
 	Conn          *websocket.Conn
 	Object        util.GameObject
 	LastDelta     util.Vector // render rotation at the last frame to keep the facing position correctly
 	HumanoidState util.HumanoidState
 	Health        int
-	ShootCoolDown *util.Timer
+	ShootCoolDown time.Duration // until the player can shoot again
 	Ammo          int
-	ClientUpdates []util.ClientUpdate // local history of inputs
+	ClientUpdates *input.Queue // inputs waiting to be simulated
 	LastInputSeq  int
+
+	DisconnectedAt time.Time         // server only, when Conn was lost; meaningless while Conn is set
+	Latency        latency.Estimator // measured by the server with pings, and sent to the clients in snapshots
 }
 
 func NewPlayer(name string) *Player {
-	sprite := assets.ManBlueGunSprite
-
 	pos := util.Vector{
 		X: util.InitialPlayerX,
 		Y: util.InitialPlayerY,
@@ -39,100 +42,94 @@ func NewPlayer(name string) *Player {
 		Object: util.GameObject{
 			Vector:   pos,
 			Rotation: util.InitialPlayerRotation,
-			Sprite:   sprite,
 		},
 		LastDelta:     pos,
 		HumanoidState: util.HumanoidStateStand,
 		Health:        util.InitialPlayerHealth,
-		ShootCoolDown: util.NewTimer(util.PlayerShootCoolDown),
+		ShootCoolDown: util.PlayerShootCoolDown,
 		Ammo:          util.InitialPlayerAmmo,
-		ClientUpdates: make([]util.ClientUpdate, 0),
+		ClientUpdates: input.NewQueue(util.InputQueueSize),
 		LastInputSeq:  0,
 	}
 }
 
-// Player.Update() updates the player and returns a new Bullet (can be nil).
-// Note that ShootCoolDown must > physics update period, or multiple bullets may be created.
-// TODO: race condition at the slice ClientUpdates?
-func (p *Player) Update() *bullet.Bullet {
-	// move 200 pixels per second
-	speed := float64(util.PlayerSpeedPerSecond / ebiten.TPS())
+// Reset restores the player to its initial state, except for its identity, connection and inputs.
+func (p *Player) Reset() {
+	p.Object.Rotation = util.InitialPlayerRotation
+	p.HumanoidState = util.HumanoidStateStand
+	p.Health = util.InitialPlayerHealth
+	p.Ammo = util.InitialPlayerAmmo
+	p.ShootCoolDown = util.PlayerShootCoolDown
+}
 
-	var b *bullet.Bullet
+// SkipInputs acknowledges every queued input without simulating it,
+// e.g. when the game is not in progress. Otherwise the client would keep replaying them.
+func (p *Player) SkipInputs() {
+	for _, msg := range p.ClientUpdates.Drain() {
+		p.LastInputSeq = max(p.LastInputSeq, msg.Seq)
+	}
+}
 
-	p.ShootCoolDown.Update()
+// ResetInputs forgets every input of the player, so its client can count them from the start again after reconnecting.
+func (p *Player) ResetInputs() {
+	p.ClientUpdates.Reset()
+	p.LastInputSeq = 0
+}
 
-	for _, msg := range p.ClientUpdates {
+// Player.Update() simulates the player's queued inputs and returns a new Bullet (can be nil).
+func (p *Player) Update() *bullet.Bullet {
+	var b *bullet.Bullet
+
+	for _, msg := range p.ClientUpdates.Drain() {
 		// skip inputs we have already simulated locally
 		if msg.Seq <= p.LastInputSeq {
 			continue
 		}
 
-		var delta util.Vector
-
-		input := msg.Keys
-		if input.A {
-			delta.X -= speed
-		}
-		if input.D {
-			delta.X += speed
-		}
-		if input.W {
-			delta.Y -= speed
-		}
-		if input.S {
-			delta.Y += speed
-		}
-
-		// check for diagonal movement
-		if delta.X != 0 && delta.Y != 0 {
-			factor := speed / math.Sqrt(delta.X*delta.X+delta.Y*delta.Y)
-			delta.X *= factor
-			delta.Y *= factor
-		}
-
-		p.Object.Vector.X += delta.X
-		p.Object.Vector.Y += delta.Y
-
-		// update rotation
-		if delta.X != 0 || delta.Y != 0 {
-			p.Object.Rotation = math.Atan2(p.LastDelta.Y, p.LastDelta.X)
-			p.LastDelta = delta
-		}
-
-		// constrain shooting at fixed intervals
-		if p.ShootCoolDown.IsReady() && input.Space {
-			p.ShootCoolDown.Reset()
-
-			spawnPos := p.Object.CalcBulletSpawnPosition()
-			b = bullet.NewBullet(spawnPos, p.Object.Rotation+util.FacingOffset)
+		if fired, ok := p.Move(msg.Keys); ok {
+			b = bullet.NewBullet(fired.Position, fired.Rotation)
+			b.OwnerID = p.ID
+			if msg.TimeStamp != 0 {
+				b.FiredAt = p.Latency.LocalTime(time.UnixMilli(int64(msg.TimeStamp)))
+			}
 		}
 
 		p.LastInputSeq = msg.Seq
 	}
 
-	// TODO: clear array
-
 	return b
 }
 
-func (p *Player) Draw(screen *ebiten.Image, debugMode bool) {
-	op := p.Object.CenterAndRotateImage()
-	op.GeoM.Translate(p.Object.Vector.X, p.Object.Vector.Y)
-	screen.DrawImage(p.Object.Sprite, op)
+// Cool lets dt of the player's shoot cooldown elapse, see sim.Player.Cool.
+func (p *Player) Cool(dt time.Duration) {
+	p.ShootCoolDown = max(p.ShootCoolDown-dt, 0)
+}
 
-	if debugMode {
-		p.Object.DrawDebugCircle(screen, 32, "")
+// Move simulates a single input, and returns the bullet it fired if any.
+// Both the server and the client's prediction go through here, so they end up at the same position.
+func (p *Player) Move(input util.KeyPress) (sim.Bullet, bool) {
+	s := sim.Player{
+		Position:  p.Object.Vector,
+		Rotation:  p.Object.Rotation,
+		LastDelta: p.LastDelta,
+		Cooldown:  p.ShootCoolDown,
 	}
+
+	// every input moves the player for one client tick, so that the server
+	// ends up at the same position as the client regardless of its own tick rate
+	fired, ok := s.Step(input, util.ClientInputPeriod)
+
+	p.Object.Vector, p.Object.Rotation = s.Position, s.Rotation
+	p.LastDelta, p.ShootCoolDown = s.LastDelta, s.Cooldown
+
+	return fired, ok
 }
 
 func (p *Player) Collider() util.Rect {
-	bounds := p.Object.Sprite.Bounds()
-
 	return util.NewRect(
 		p.Object.Vector.X,
 		p.Object.Vector.Y,
-		float64(bounds.Dx()),
-		float64(bounds.Dy()),
+		util.PlayerWidth,
+		util.PlayerHeight,
 	)
 }
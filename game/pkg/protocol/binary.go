@@ -0,0 +1,417 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/coder/websocket"
+	"github.com/google/uuid"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
+	"github.com/livingpool/top-down-shooter/game/util"
+)
+
+// The binary encoding. Every message starts with a 2 byte header: the version, then the message type.
+// Integers are varints, ids are the 16 raw bytes of the uuid, and strings are prefixed with their length.
+// Keys are packed into a single byte. Positions are quantized to 1/8 of a pixel,
+// and rotations to 1/65536 of a full turn.
+
+const binaryVersion = 1
+
+// message types
+const (
+	binaryInput byte = iota + 1
+	binarySnapshot
+	binaryEvent
+	binaryAck
+	binaryPing
+	binaryPong
+	binaryChat
+	binaryJoin
+	binaryLeave
+	binaryError
+)
+
+var binaryTypes = map[string]byte{
+	util.MsgTypeInput:    binaryInput,
+	util.MsgTypeSnapshot: binarySnapshot,
+	util.MsgTypeEvent:    binaryEvent,
+	util.MsgTypeAck:      binaryAck,
+	util.MsgTypePing:     binaryPing,
+	util.MsgTypePong:     binaryPong,
+	util.MsgTypeChat:     binaryChat,
+	util.MsgTypeJoin:     binaryJoin,
+	util.MsgTypeLeave:    binaryLeave,
+	util.MsgTypeError:    binaryError,
+}
+
+// bits of the packed keys
+const (
+	keyW byte = 1 << iota
+	keyS
+	keyA
+	keyD
+	keySpace
+)
+
+const positionScale = 8.0
+
+var errShortMessage = errors.New("message is too short")
+
+type Binary struct{}
+
+func (Binary) Subprotocol() string { return SubprotocolBinary }
+
+func (Binary) MessageType() websocket.MessageType { return websocket.MessageBinary }
+
+func (Binary) Encode(msg state.Msg) ([]byte, error) {
+	if err := checkPayload(msg); err != nil {
+		return nil, err
+	}
+
+	e := &encoder{buf: make([]byte, 0, 64)}
+	e.header(binaryTypes[msg.Type])
+
+	switch payload := msg.Payload.(type) {
+	case util.ClientUpdate:
+		e.id(payload.PlayerId)
+		e.byte(packKeys(payload.Keys))
+		e.int(int64(payload.Seq))
+		e.int(int64(payload.TimeStamp))
+	case util.SnapshotAck:
+		e.int(int64(payload.Seq))
+	case state.ServerUpdate:
+		e.id(payload.GameId)
+		e.int(int64(payload.Seq))
+		e.int(int64(payload.Baseline))
+		e.int(int64(payload.TimeStamp))
+		e.string(payload.Phase)
+		e.int(int64(len(payload.Players)))
+		for _, p := range payload.Players {
+			e.id(p.ID)
+			e.string(p.Name)
+			e.int(int64(p.Team))
+			e.position(p.X, p.Y)
+			e.rotation(p.Rotation)
+			e.int(int64(p.Health))
+			e.int(int64(p.Ammo))
+			e.int(int64(p.LastInputSeq))
+			e.int(int64(p.RTT))
+			e.int(int64(p.Jitter))
+			e.int(int64(p.ClockOffset))
+		}
+		e.int(int64(len(payload.Bullets)))
+		for _, b := range payload.Bullets {
+			e.id(b.ID)
+			e.position(b.X, b.Y)
+			e.rotation(b.Rotation)
+		}
+		e.int(int64(len(payload.Zombies)))
+		for _, z := range payload.Zombies {
+			e.id(z.ID)
+			e.position(z.X, z.Y)
+			e.rotation(z.Rotation)
+		}
+		e.int(int64(len(payload.Removed)))
+		for _, id := range payload.Removed {
+			e.id(id)
+		}
+	case state.GameEvent:
+		e.string(payload.Event)
+		e.id(payload.GameId)
+		e.string(payload.Phase)
+		e.string(payload.PrevPhase)
+		e.int(int64(payload.EndsAt))
+		e.int(int64(payload.TimeStamp))
+	case state.Ping:
+		e.int(int64(payload.Seq))
+		e.int(int64(payload.SentAt))
+	case state.Pong:
+		e.int(int64(payload.Seq))
+		e.int(int64(payload.SentAt))
+		e.int(int64(payload.RepliedAt))
+	case state.Chat:
+		e.id(payload.PlayerId)
+		e.string(payload.Name)
+		e.string(payload.Channel)
+		e.string(payload.Text)
+		e.int(int64(payload.TimeStamp))
+	case state.Presence:
+		e.id(payload.PlayerId)
+		e.string(payload.Name)
+	case state.ProtocolError:
+		e.string(payload.Message)
+	}
+
+	return e.buf, e.err
+}
+
+func (Binary) Decode(data []byte) (state.Msg, error) {
+	if len(data) < 2 {
+		return state.Msg{}, errShortMessage
+	}
+	if data[0] != binaryVersion {
+		return state.Msg{}, fmt.Errorf("unsupported protocol version: %d", data[0])
+	}
+	d := &decoder{buf: data[2:]}
+
+	var msg state.Msg
+	switch data[1] {
+	case binaryInput:
+		var payload util.ClientUpdate
+		payload.PlayerId = d.id()
+		payload.Keys = unpackKeys(d.byte())
+		payload.Seq = int(d.int())
+		payload.TimeStamp = int(d.int())
+		msg = state.Msg{Type: util.MsgTypeInput, Payload: payload}
+	case binaryAck:
+		var payload util.SnapshotAck
+		payload.Seq = int(d.int())
+		msg = state.Msg{Type: util.MsgTypeAck, Payload: payload}
+	case binarySnapshot:
+		var payload state.ServerUpdate
+		payload.GameId = d.id()
+		payload.Seq = int(d.int())
+		payload.Baseline = int(d.int())
+		payload.TimeStamp = int(d.int())
+		payload.Phase = d.string()
+		payload.Players = make([]state.PlayerState, d.length())
+		for i := range payload.Players {
+			p := &payload.Players[i]
+			p.ID = d.id()
+			p.Name = d.string()
+			p.Team = int(d.int())
+			p.X, p.Y = d.position()
+			p.Rotation = d.rotation()
+			p.Health = int(d.int())
+			p.Ammo = int(d.int())
+			p.LastInputSeq = int(d.int())
+			p.RTT = int(d.int())
+			p.Jitter = int(d.int())
+			p.ClockOffset = int(d.int())
+		}
+		payload.Bullets = make([]state.BulletState, d.length())
+		for i := range payload.Bullets {
+			b := &payload.Bullets[i]
+			b.ID = d.id()
+			b.X, b.Y = d.position()
+			b.Rotation = d.rotation()
+		}
+		payload.Zombies = make([]state.ZombieState, d.length())
+		for i := range payload.Zombies {
+			z := &payload.Zombies[i]
+			z.ID = d.id()
+			z.X, z.Y = d.position()
+			z.Rotation = d.rotation()
+		}
+		if n := d.length(); n > 0 {
+			payload.Removed = make([]string, n)
+			for i := range payload.Removed {
+				payload.Removed[i] = d.id()
+			}
+		}
+		msg = state.Msg{Type: util.MsgTypeSnapshot, Payload: payload}
+	case binaryEvent:
+		var payload state.GameEvent
+		payload.Event = d.string()
+		payload.GameId = d.id()
+		payload.Phase = d.string()
+		payload.PrevPhase = d.string()
+		payload.EndsAt = int(d.int())
+		payload.TimeStamp = int(d.int())
+		msg = state.Msg{Type: util.MsgTypeEvent, Payload: payload}
+	case binaryPing:
+		var payload state.Ping
+		payload.Seq = int(d.int())
+		payload.SentAt = int(d.int())
+		msg = state.Msg{Type: util.MsgTypePing, Payload: payload}
+	case binaryPong:
+		var payload state.Pong
+		payload.Seq = int(d.int())
+		payload.SentAt = int(d.int())
+		payload.RepliedAt = int(d.int())
+		msg = state.Msg{Type: util.MsgTypePong, Payload: payload}
+	case binaryChat:
+		var payload state.Chat
+		payload.PlayerId = d.id()
+		payload.Name = d.string()
+		payload.Channel = d.string()
+		payload.Text = d.string()
+		payload.TimeStamp = int(d.int())
+		msg = state.Msg{Type: util.MsgTypeChat, Payload: payload}
+	case binaryJoin, binaryLeave:
+		var payload state.Presence
+		payload.PlayerId = d.id()
+		payload.Name = d.string()
+		msg = state.Msg{Type: util.MsgTypeJoin, Payload: payload}
+		if data[1] == binaryLeave {
+			msg.Type = util.MsgTypeLeave
+		}
+	case binaryError:
+		var payload state.ProtocolError
+		payload.Message = d.string()
+		msg = state.Msg{Type: util.MsgTypeError, Payload: payload}
+	default:
+		return state.Msg{}, fmt.Errorf("%w: %d", ErrUnknownType, data[1])
+	}
+
+	return msg, d.err
+}
+
+func packKeys(k util.KeyPress) byte {
+	var b byte
+	if k.W {
+		b |= keyW
+	}
+	if k.S {
+		b |= keyS
+	}
+	if k.A {
+		b |= keyA
+	}
+	if k.D {
+		b |= keyD
+	}
+	if k.Space {
+		b |= keySpace
+	}
+	return b
+}
+
+func unpackKeys(b byte) util.KeyPress {
+	return util.KeyPress{
+		W:     b&keyW != 0,
+		S:     b&keyS != 0,
+		A:     b&keyA != 0,
+		D:     b&keyD != 0,
+		Space: b&keySpace != 0,
+	}
+}
+
+// encoder appends to buf, and remembers the first error so the caller only checks once.
+type encoder struct {
+	buf []byte
+	err error
+}
+
+func (e *encoder) header(msgType byte) {
+	e.buf = append(e.buf, binaryVersion, msgType)
+}
+
+func (e *encoder) byte(b byte) {
+	e.buf = append(e.buf, b)
+}
+
+func (e *encoder) int(n int64) {
+	e.buf = binary.AppendVarint(e.buf, n)
+}
+
+func (e *encoder) string(s string) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) id(s string) {
+	id, err := uuid.Parse(s)
+	if err != nil && e.err == nil {
+		e.err = fmt.Errorf("id %q is not uuid", s)
+	}
+	e.buf = append(e.buf, id[:]...)
+}
+
+func (e *encoder) position(x, y float64) {
+	e.int(int64(math.Round(x * positionScale)))
+	e.int(int64(math.Round(y * positionScale)))
+}
+
+func (e *encoder) rotation(r float64) {
+	turn := math.Mod(r, 2*math.Pi)
+	if turn < 0 {
+		turn += 2 * math.Pi
+	}
+	quantized := int64(math.Round(turn/(2*math.Pi)*65536)) & 0xffff // a full turn wraps around to 0
+	e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(quantized))
+}
+
+// decoder consumes buf, and remembers the first error so the caller only checks once.
+// After an error, every read returns a zero value.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+	d.buf = nil
+}
+
+func (d *decoder) byte() byte {
+	if len(d.buf) < 1 {
+		d.fail(errShortMessage)
+		return 0
+	}
+	b := d.buf[0]
+	d.buf = d.buf[1:]
+	return b
+}
+
+func (d *decoder) int() int64 {
+	n, size := binary.Varint(d.buf)
+	if size <= 0 {
+		d.fail(errShortMessage)
+		return 0
+	}
+	d.buf = d.buf[size:]
+	return n
+}
+
+// length reads the number of items that follow, bounded by what's left so a bad message can't make us allocate a lot.
+func (d *decoder) length() int {
+	n := d.int()
+	if n < 0 || n > int64(len(d.buf)) {
+		d.fail(fmt.Errorf("invalid length: %d", n))
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) string() string {
+	n, size := binary.Uvarint(d.buf)
+	if size <= 0 || n > uint64(len(d.buf)-size) {
+		d.fail(errShortMessage)
+		return ""
+	}
+	s := string(d.buf[size : size+int(n)])
+	d.buf = d.buf[size+int(n):]
+	return s
+}
+
+func (d *decoder) id() string {
+	if len(d.buf) < 16 {
+		d.fail(errShortMessage)
+		return ""
+	}
+	id := uuid.UUID(d.buf[:16])
+	d.buf = d.buf[16:]
+	return id.String()
+}
+
+func (d *decoder) position() (float64, float64) {
+	x := float64(d.int()) / positionScale
+	y := float64(d.int()) / positionScale
+	return x, y
+}
+
+// rotation returns the angle in [-pi, pi), which is what math.Atan2 gives the players.
+func (d *decoder) rotation() float64 {
+	if len(d.buf) < 2 {
+		d.fail(errShortMessage)
+		return 0
+	}
+	r := float64(binary.BigEndian.Uint16(d.buf)) / 65536 * 2 * math.Pi
+	d.buf = d.buf[2:]
+	return math.Remainder(r, 2*math.Pi)
+}
@@ -0,0 +1,137 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/coder/websocket"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
+	"github.com/livingpool/top-down-shooter/game/util"
+)
+
+// The wire protocol between the clients and the server.
+//
+// Two encodings are supported: JSON, which is easy to debug, and a compact binary encoding.
+// The client picks one by offering it as a websocket subprotocol when dialing /join.
+// Clients that don't offer any subprotocol get JSON.
+
+const (
+	SubprotocolBinary = "tds.binary.v1"
+	SubprotocolJSON   = "tds.json.v1"
+)
+
+// Subprotocols lists the subprotocols the server accepts, in order of preference.
+var Subprotocols = []string{SubprotocolBinary, SubprotocolJSON}
+
+// ErrUnknownType is returned for messages of a type that isn't part of the protocol,
+// or that the receiving end doesn't handle.
+var ErrUnknownType = errors.New("unknown message type")
+
+// Codec encodes and decodes the messages sent over a websocket connection.
+// Every message is a state.Msg, see util.MsgTypeInput and the like for the payload of each type.
+type Codec interface {
+	Subprotocol() string
+	MessageType() websocket.MessageType
+	Encode(msg state.Msg) ([]byte, error)
+	Decode(data []byte) (state.Msg, error)
+}
+
+// ForSubprotocol returns the codec of a negotiated subprotocol; JSON if none was negotiated.
+func ForSubprotocol(subprotocol string) Codec {
+	switch subprotocol {
+	case SubprotocolBinary:
+		return Binary{}
+	default:
+		return JSON{}
+	}
+}
+
+type JSON struct{}
+
+func (JSON) Subprotocol() string { return SubprotocolJSON }
+
+func (JSON) MessageType() websocket.MessageType { return websocket.MessageText }
+
+func (JSON) Encode(msg state.Msg) ([]byte, error) {
+	if err := checkPayload(msg); err != nil {
+		return nil, err
+	}
+	return json.Marshal(msg)
+}
+
+// Decode unmarshals the envelope first, then its payload according to its type.
+func (JSON) Decode(data []byte) (state.Msg, error) {
+	var envelope struct {
+		Type    string          `json:"type"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return state.Msg{}, err
+	}
+
+	msg := state.Msg{Type: envelope.Type}
+	var err error
+	switch envelope.Type {
+	case util.MsgTypeInput:
+		msg.Payload, err = unmarshal[util.ClientUpdate](envelope.Payload)
+	case util.MsgTypeAck:
+		msg.Payload, err = unmarshal[util.SnapshotAck](envelope.Payload)
+	case util.MsgTypeSnapshot:
+		msg.Payload, err = unmarshal[state.ServerUpdate](envelope.Payload)
+	case util.MsgTypeEvent:
+		msg.Payload, err = unmarshal[state.GameEvent](envelope.Payload)
+	case util.MsgTypePing:
+		msg.Payload, err = unmarshal[state.Ping](envelope.Payload)
+	case util.MsgTypePong:
+		msg.Payload, err = unmarshal[state.Pong](envelope.Payload)
+	case util.MsgTypeChat:
+		msg.Payload, err = unmarshal[state.Chat](envelope.Payload)
+	case util.MsgTypeJoin, util.MsgTypeLeave:
+		msg.Payload, err = unmarshal[state.Presence](envelope.Payload)
+	case util.MsgTypeError:
+		msg.Payload, err = unmarshal[state.ProtocolError](envelope.Payload)
+	default:
+		return msg, fmt.Errorf("%w: %q", ErrUnknownType, envelope.Type)
+	}
+
+	return msg, err
+}
+
+func unmarshal[T any](data json.RawMessage) (any, error) {
+	var payload T
+	err := json.Unmarshal(data, &payload)
+	return payload, err
+}
+
+// checkPayload makes sure the payload is what the message's type says it is.
+func checkPayload(msg state.Msg) error {
+	var ok bool
+	switch msg.Type {
+	case util.MsgTypeInput:
+		_, ok = msg.Payload.(util.ClientUpdate)
+	case util.MsgTypeAck:
+		_, ok = msg.Payload.(util.SnapshotAck)
+	case util.MsgTypeSnapshot:
+		_, ok = msg.Payload.(state.ServerUpdate)
+	case util.MsgTypeEvent:
+		_, ok = msg.Payload.(state.GameEvent)
+	case util.MsgTypePing:
+		_, ok = msg.Payload.(state.Ping)
+	case util.MsgTypePong:
+		_, ok = msg.Payload.(state.Pong)
+	case util.MsgTypeChat:
+		_, ok = msg.Payload.(state.Chat)
+	case util.MsgTypeJoin, util.MsgTypeLeave:
+		_, ok = msg.Payload.(state.Presence)
+	case util.MsgTypeError:
+		_, ok = msg.Payload.(state.ProtocolError)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownType, msg.Type)
+	}
+
+	if !ok {
+		return fmt.Errorf("unexpected payload for %q message: %T", msg.Type, msg.Payload)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package protocol
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
+	"github.com/livingpool/top-down-shooter/game/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	input := util.ClientUpdate{
+		PlayerId:  uuid.NewString(),
+		Keys:      util.KeyPress{W: true, D: true, Space: true},
+		Seq:       42,
+		TimeStamp: 1_700_000_000_000,
+	}
+	snapshot := state.ServerUpdate{
+		GameId:    uuid.NewString(),
+		Seq:       7,
+		Baseline:  5,
+		TimeStamp: 1_700_000_000_045,
+		Phase:     "in_progress",
+		Players: []state.PlayerState{
+			{ID: uuid.NewString(), Name: "tim", Team: 1, X: 400, Y: 300, Rotation: -math.Pi / 2, Health: 5, Ammo: 10, LastInputSeq: 42},
+		},
+		Bullets: []state.BulletState{{ID: uuid.NewString(), X: 410.5, Y: -20.25, Rotation: 1}},
+		Zombies: []state.ZombieState{},
+		Removed: []string{uuid.NewString()},
+	}
+	event := state.GameEvent{
+		Event:     state.EventPhaseChanged,
+		GameId:    snapshot.GameId,
+		Phase:     "countdown",
+		PrevPhase: "waiting",
+		EndsAt:    1_700_000_003_000,
+		TimeStamp: 1_700_000_000_000,
+	}
+	presence := state.Presence{PlayerId: input.PlayerId, Name: "tim"}
+
+	msgs := []state.Msg{
+		{Type: util.MsgTypeInput, Payload: input},
+		{Type: util.MsgTypeAck, Payload: util.SnapshotAck{Seq: 7}},
+		{Type: util.MsgTypeSnapshot, Payload: snapshot},
+		{Type: util.MsgTypeEvent, Payload: event},
+		{Type: util.MsgTypePing, Payload: state.Ping{Seq: 1, SentAt: 1_700_000_000_000}},
+		{Type: util.MsgTypePong, Payload: state.Pong{Seq: 1, SentAt: 1_700_000_000_000, RepliedAt: 1_700_000_000_020}},
+		{Type: util.MsgTypeChat, Payload: state.Chat{PlayerId: input.PlayerId, Name: "tim", Channel: util.ChatChannelTeam, Text: "hi", TimeStamp: 1_700_000_000_000}},
+		{Type: util.MsgTypeJoin, Payload: presence},
+		{Type: util.MsgTypeLeave, Payload: presence},
+		{Type: util.MsgTypeError, Payload: state.ProtocolError{Message: "nope"}},
+	}
+
+	for _, codec := range []Codec{JSON{}, Binary{}} {
+		for _, msg := range msgs {
+			data, err := codec.Encode(msg)
+			require.NoError(t, err)
+
+			decoded, err := codec.Decode(data)
+			require.NoError(t, err)
+
+			// quantized values may be off by a little
+			if s, ok := decoded.Payload.(state.ServerUpdate); ok {
+				assert.InDelta(t, snapshot.Players[0].Rotation, s.Players[0].Rotation, 0.001)
+				assert.InDelta(t, snapshot.Bullets[0].Rotation, s.Bullets[0].Rotation, 0.001)
+				s.Players[0].Rotation = snapshot.Players[0].Rotation
+				s.Bullets[0].Rotation = snapshot.Bullets[0].Rotation
+				decoded.Payload = s
+			}
+			assert.Equal(t, msg, decoded, codec.Subprotocol())
+		}
+	}
+}
+
+func TestBinaryIsSmaller(t *testing.T) {
+	input := state.Msg{
+		Type:    util.MsgTypeInput,
+		Payload: util.ClientUpdate{PlayerId: uuid.NewString(), Keys: util.KeyPress{A: true}, Seq: 1000, TimeStamp: 1_700_000_000_000},
+	}
+
+	jsonData, err := JSON{}.Encode(input)
+	require.NoError(t, err)
+	binaryData, err := Binary{}.Encode(input)
+	require.NoError(t, err)
+
+	assert.Less(t, len(binaryData), len(jsonData)/4)
+}
+
+func TestBadMessages(t *testing.T) {
+	_, err := Binary{}.Decode([]byte{binaryVersion})
+	assert.Error(t, err)
+
+	_, err = Binary{}.Decode([]byte{binaryVersion + 1, binaryInput})
+	assert.Error(t, err)
+
+	_, err = Binary{}.Decode([]byte{binaryVersion, 0xff})
+	assert.ErrorIs(t, err, ErrUnknownType)
+
+	_, err = JSON{}.Decode([]byte(`{"type":"dance","payload":{}}`))
+	assert.ErrorIs(t, err, ErrUnknownType)
+
+	// truncated input
+	data, err := Binary{}.Encode(state.Msg{Type: util.MsgTypeInput, Payload: util.ClientUpdate{PlayerId: uuid.NewString(), Seq: 1}})
+	require.NoError(t, err)
+	_, err = Binary{}.Decode(data[:10])
+	assert.Error(t, err)
+
+	// the payload doesn't match the type
+	for _, codec := range []Codec{JSON{}, Binary{}} {
+		_, err = codec.Encode(state.Msg{Type: util.MsgTypeInput, Payload: state.Ping{}})
+		assert.Error(t, err, codec.Subprotocol())
+	}
+}
+
+func TestRouter(t *testing.T) {
+	router := NewRouter()
+
+	var pinged state.Ping
+	Handle(router, util.MsgTypePing, func(ping state.Ping) error {
+		pinged = ping
+		return nil
+	})
+
+	require.NoError(t, router.Route(state.Msg{Type: util.MsgTypePing, Payload: state.Ping{Seq: 3}}))
+	assert.Equal(t, 3, pinged.Seq)
+
+	err := router.Route(state.Msg{Type: util.MsgTypeSnapshot, Payload: state.ServerUpdate{}})
+	assert.ErrorIs(t, err, ErrUnknownType, "types without a handler are rejected")
+
+	err = router.Route(state.Msg{Type: util.MsgTypePing, Payload: state.Pong{}})
+	assert.Error(t, err, "the payload must match the type")
+}
@@ -0,0 +1,41 @@
+package protocol
+
+import (
+	"fmt"
+
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
+)
+
+// Router dispatches messages to the handler registered for their type.
+// It is used by both ends of a connection; each registers the types it accepts.
+type Router struct {
+	handlers map[string]func(state.Msg) error
+}
+
+func NewRouter() *Router {
+	return &Router{
+		handlers: make(map[string]func(state.Msg) error),
+	}
+}
+
+// Handle registers the handler of a message type. The payload is passed to it as T,
+// which must be the payload type of msgType, e.g. util.ClientUpdate for util.MsgTypeInput.
+func Handle[T any](r *Router, msgType string, handle func(T) error) {
+	r.handlers[msgType] = func(msg state.Msg) error {
+		payload, ok := msg.Payload.(T)
+		if !ok {
+			return fmt.Errorf("unexpected payload for %q message: %T", msg.Type, msg.Payload)
+		}
+		return handle(payload)
+	}
+}
+
+// Route calls the handler of the message's type.
+// It returns ErrUnknownType if no handler is registered for it.
+func (r *Router) Route(msg state.Msg) error {
+	handle, exists := r.handlers[msg.Type]
+	if !exists {
+		return fmt.Errorf("%w: %q", ErrUnknownType, msg.Type)
+	}
+	return handle(msg)
+}
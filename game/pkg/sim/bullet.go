@@ -0,0 +1,20 @@
+package sim
+
+import (
+	"math"
+	"time"
+)
+
+// Bullet is the part of a bullet's state that the simulation changes.
+type Bullet struct {
+	Position Vector
+	Rotation float64 // 0 flies up
+}
+
+// Step moves the bullet along its rotation for a duration of dt.
+func (b *Bullet) Step(dt time.Duration) {
+	speed := BulletSpeedPerSecond * dt.Seconds()
+
+	b.Position.X += math.Sin(b.Rotation) * speed
+	b.Position.Y += math.Cos(b.Rotation) * -speed
+}
@@ -0,0 +1,218 @@
+package sim
+
+import (
+	"container/heap"
+	"math"
+)
+
+// Navigation around obstacles.
+//
+// The world is divided into a Grid of square cells, and the cells an obstacle covers are blocked.
+// Paths are found between cells with A*, moving in 8 directions without cutting corners.
+// A Route caches the path of a zombie toward its target, and only looks for a new one
+// once the target has moved more than ZombieRepathDistance.
+
+// Grid is a navigation grid. Cells are free unless blocked.
+type Grid struct {
+	Origin   Vector // top left corner of the grid
+	CellSize float64
+	Cols     int
+	Rows     int
+	blocked  []bool
+}
+
+func NewGrid(origin Vector, cols, rows int, cellSize float64) *Grid {
+	return &Grid{
+		Origin:   origin,
+		CellSize: cellSize,
+		Cols:     cols,
+		Rows:     rows,
+		blocked:  make([]bool, cols*rows),
+	}
+}
+
+// cell returns the index of the cell pos is in, if it is on the grid.
+func (g *Grid) cell(pos Vector) (int, bool) {
+	col := int(math.Floor((pos.X - g.Origin.X) / g.CellSize))
+	row := int(math.Floor((pos.Y - g.Origin.Y) / g.CellSize))
+	if col < 0 || col >= g.Cols || row < 0 || row >= g.Rows {
+		return 0, false
+	}
+	return row*g.Cols + col, true
+}
+
+// center returns the center of the cell at index i.
+func (g *Grid) center(i int) Vector {
+	col, row := i%g.Cols, i/g.Cols
+	return Vector{
+		X: g.Origin.X + (float64(col)+0.5)*g.CellSize,
+		Y: g.Origin.Y + (float64(row)+0.5)*g.CellSize,
+	}
+}
+
+// Blocked reports whether pos is in a blocked cell. Positions off the grid are free.
+func (g *Grid) Blocked(pos Vector) bool {
+	i, ok := g.cell(pos)
+	return ok && g.blocked[i]
+}
+
+// BlockCircle blocks every cell whose center is within radius of center.
+func (g *Grid) BlockCircle(center Vector, radius float64) {
+	for i := range g.blocked {
+		if g.center(i).Sub(center).Length() <= radius {
+			g.blocked[i] = true
+		}
+	}
+}
+
+// BlockRect blocks every cell whose center is within a rectangle of width and height,
+// centered at center and rotated by rotation, then grown by margin on every side.
+func (g *Grid) BlockRect(center Vector, width, height, rotation, margin float64) {
+	sin, cos := math.Sincos(-rotation)
+	halfW, halfH := width/2+margin, height/2+margin
+
+	for i := range g.blocked {
+		// the cell's center in the rectangle's frame
+		d := g.center(i).Sub(center)
+		x, y := d.X*cos-d.Y*sin, d.X*sin+d.Y*cos
+		if math.Abs(x) <= halfW && math.Abs(y) <= halfH {
+			g.blocked[i] = true
+		}
+	}
+}
+
+// Path returns the waypoints from `from` to `to` around the blocked cells:
+// the centers of the cells in between, then `to` itself.
+// The cells of `from` and `to` are never considered blocked, so a path can start or end next to an obstacle.
+// It reports false if either is off the grid or there is no way around.
+func (g *Grid) Path(from, to Vector) ([]Vector, bool) {
+	start, ok := g.cell(from)
+	if !ok {
+		return nil, false
+	}
+	goal, ok := g.cell(to)
+	if !ok {
+		return nil, false
+	}
+
+	free := func(i int) bool { return i == start || i == goal || !g.blocked[i] }
+
+	cost := map[int]float64{start: 0}
+	cameFrom := map[int]int{}
+	open := &openSet{{cell: start, priority: g.heuristic(start, goal)}}
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(openCell).cell
+		if current == goal {
+			break
+		}
+
+		col, row := current%g.Cols, current/g.Cols
+		for _, dir := range directions {
+			c, r := col+dir.col, row+dir.row
+			if c < 0 || c >= g.Cols || r < 0 || r >= g.Rows || !free(r*g.Cols+c) {
+				continue
+			}
+			// moving diagonally would clip the corners of the cells next to both ends
+			if dir.col != 0 && dir.row != 0 && (!free(row*g.Cols+c) || !free(r*g.Cols+col)) {
+				continue
+			}
+
+			next := r*g.Cols + c
+			nextCost := cost[current] + dir.cost
+			if known, seen := cost[next]; seen && known <= nextCost {
+				continue
+			}
+			cost[next] = nextCost
+			cameFrom[next] = current
+			heap.Push(open, openCell{cell: next, priority: nextCost + g.heuristic(next, goal)})
+		}
+	}
+
+	if _, reached := cost[goal]; !reached {
+		return nil, false
+	}
+
+	path := []Vector{to}
+	for i := cameFrom[goal]; goal != start && i != start; i = cameFrom[i] {
+		path = append(path, g.center(i))
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path, true
+}
+
+// heuristic is the octile distance between two cells, in cells.
+func (g *Grid) heuristic(a, b int) float64 {
+	dx := math.Abs(float64(a%g.Cols - b%g.Cols))
+	dy := math.Abs(float64(a/g.Cols - b/g.Cols))
+	return max(dx, dy) + (math.Sqrt2-1)*min(dx, dy)
+}
+
+var directions = []struct {
+	col, row int
+	cost     float64
+}{
+	{1, 0, 1}, {-1, 0, 1}, {0, 1, 1}, {0, -1, 1},
+	{1, 1, math.Sqrt2}, {1, -1, math.Sqrt2}, {-1, 1, math.Sqrt2}, {-1, -1, math.Sqrt2},
+}
+
+// openCell is a cell waiting to be explored, cheapest first.
+type openCell struct {
+	cell     int
+	priority float64
+}
+
+// openSet implements heap.Interface.
+type openSet []openCell
+
+func (s openSet) Len() int           { return len(s) }
+func (s openSet) Less(i, j int) bool { return s[i].priority < s[j].priority }
+func (s openSet) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s *openSet) Push(x any)        { *s = append(*s, x.(openCell)) }
+func (s *openSet) Pop() any {
+	old := *s
+	x := old[len(old)-1]
+	*s = old[:len(old)-1]
+	return x
+}
+
+// Route is the cached path of a zombie toward its target.
+// The zero value has no path yet.
+type Route struct {
+	path     []Vector // waypoints left, the next one first
+	from     Vector   // where the zombie was when the path was searched for
+	target   Vector   // where the target was when the path was searched for
+	searched bool
+}
+
+// Next returns where to head for on the way from pos to target.
+// A new path is only searched for once the target has moved more than ZombieRepathDistance
+// since the last search, or the zombie has if none was found, e.g. it was off the grid.
+// Without a path, where to head for is the target itself.
+func (r *Route) Next(g *Grid, pos, target Vector) Vector {
+	if g == nil {
+		return target
+	}
+
+	if !r.searched ||
+		target.Sub(r.target).Length() > ZombieRepathDistance ||
+		(r.path == nil && pos.Sub(r.from).Length() > ZombieRepathDistance) {
+		r.path, _ = g.Path(pos, target)
+		r.from, r.target = pos, target
+		r.searched = true
+	}
+
+	// skip the waypoints that have been reached
+	for len(r.path) > 1 && pos.Sub(r.path[0]).Length() < g.CellSize/2 {
+		r.path = r.path[1:]
+	}
+
+	// the last waypoint is where the target was, but it may have moved a little since
+	if len(r.path) <= 1 {
+		return target
+	}
+	return r.path[0]
+}
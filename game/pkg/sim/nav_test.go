@@ -0,0 +1,102 @@
+package sim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cellSize = 32
+
+// at returns the center of the cell at col and row of a grid at the origin.
+func at(col, row float64) Vector {
+	return Vector{X: (col + 0.5) * cellSize, Y: (row + 0.5) * cellSize}
+}
+
+// wallGrid is a 10x10 grid with a wall down column 5, open at the bottom row.
+func wallGrid() *Grid {
+	g := NewGrid(Vector{}, 10, 10, cellSize)
+	for row := range 9 {
+		g.BlockRect(at(5, float64(row)), cellSize, cellSize, 0, 0)
+	}
+	return g
+}
+
+func TestGridBlock(t *testing.T) {
+	g := wallGrid()
+	assert.True(t, g.Blocked(at(5, 0)))
+	assert.False(t, g.Blocked(at(5, 9)))
+	assert.False(t, g.Blocked(at(4, 0)))
+	assert.False(t, g.Blocked(Vector{X: -1, Y: -1}), "off the grid")
+
+	g = NewGrid(Vector{}, 10, 10, cellSize)
+	g.BlockCircle(at(5, 5), cellSize)
+	assert.True(t, g.Blocked(at(4, 5)))
+	assert.False(t, g.Blocked(at(4, 4)))
+
+	// a rectangle tilted by 90 degrees covers a column instead of a row
+	g = NewGrid(Vector{}, 10, 10, cellSize)
+	g.BlockRect(at(5, 5), 5*cellSize, cellSize, FacingOffset, 0)
+	assert.True(t, g.Blocked(at(5, 3)))
+	assert.False(t, g.Blocked(at(3, 5)))
+
+	// grown by a margin
+	g = NewGrid(Vector{}, 10, 10, cellSize)
+	g.BlockRect(at(5, 5), cellSize, cellSize, 0, cellSize)
+	assert.True(t, g.Blocked(at(4, 4)))
+	assert.False(t, g.Blocked(at(3, 3)))
+}
+
+func TestGridPath(t *testing.T) {
+	g := wallGrid()
+	from, to := at(1, 0), at(8, 0)
+
+	path, ok := g.Path(from, to)
+	require.True(t, ok)
+	assert.Equal(t, to, path[len(path)-1])
+
+	// it goes around the bottom of the wall, never through it
+	deepest := 0.0
+	for _, waypoint := range path {
+		assert.False(t, g.Blocked(waypoint), "waypoint %v is in the wall", waypoint)
+		deepest = max(deepest, waypoint.Y)
+	}
+	assert.Equal(t, at(0, 9).Y, deepest)
+
+	// nowhere to go once the gap is closed
+	g.BlockRect(at(5, 9), cellSize, cellSize, 0, 0)
+	_, ok = g.Path(from, to)
+	assert.False(t, ok)
+
+	_, ok = g.Path(from, at(20, 20))
+	assert.False(t, ok, "off the grid")
+}
+
+func TestRoute(t *testing.T) {
+	g := wallGrid()
+	var r Route
+	pos, target := at(1, 0), at(8, 0)
+
+	// head down, around the wall, instead of straight at the target
+	next := r.Next(g, pos, target)
+	assert.Greater(t, next.Y, pos.Y)
+	path := r.path
+
+	// the path is kept while the target moves a little
+	r.Next(g, pos, target.Add(Vector{Y: ZombieRepathDistance / 2}))
+	assert.Equal(t, path, r.path)
+
+	// but not once it has moved far
+	r.Next(g, pos, at(1, 9))
+	assert.NotEqual(t, path, r.path)
+
+	// a zombie off the grid has no path, until it walks onto it
+	r = Route{}
+	assert.Equal(t, target, r.Next(g, Vector{X: -100, Y: 0}, target))
+	assert.Equal(t, target, r.Next(g, Vector{X: -50, Y: 0}, target))
+	assert.NotEqual(t, target, r.Next(g, pos, target))
+
+	// without a grid, zombies chase in a straight line
+	assert.Equal(t, target, r.Next(nil, pos, target))
+}
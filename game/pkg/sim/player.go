@@ -0,0 +1,82 @@
+package sim
+
+import (
+	"math"
+	"time"
+)
+
+// Player is the part of a player's state that the simulation changes.
+type Player struct {
+	Position  Vector
+	Rotation  float64
+	LastDelta Vector        // the last movement, which the player turns to face on the next one
+	Cooldown  time.Duration // until the player can shoot again
+}
+
+// NewPlayer returns a player standing at pos, facing up, that can shoot once its cooldown has elapsed.
+func NewPlayer(pos Vector) Player {
+	return Player{
+		Position:  pos,
+		Rotation:  InitialPlayerRotation,
+		LastDelta: pos,
+		Cooldown:  PlayerShootCoolDown,
+	}
+}
+
+// Cool lets dt of the player's shoot cooldown elapse.
+// It runs with time rather than with the inputs that are stepped, so it is kept apart from Step.
+func (p *Player) Cool(dt time.Duration) {
+	p.Cooldown = max(p.Cooldown-dt, 0)
+}
+
+// Step moves the player by input for a duration of dt,
+// and returns the bullet it fired, if it was ready to shoot, see Cool.
+func (p *Player) Step(input Input, dt time.Duration) (Bullet, bool) {
+	speed := PlayerSpeedPerSecond * dt.Seconds()
+
+	var delta Vector
+
+	if input.A {
+		delta.X -= speed
+	}
+	if input.D {
+		delta.X += speed
+	}
+	if input.W {
+		delta.Y -= speed
+	}
+	if input.S {
+		delta.Y += speed
+	}
+
+	// check for diagonal movement
+	if delta.X != 0 && delta.Y != 0 {
+		delta = delta.Normalize().Scale(speed)
+	}
+
+	p.Position = p.Position.Add(delta)
+
+	// update rotation
+	if delta.X != 0 || delta.Y != 0 {
+		p.Rotation = math.Atan2(p.LastDelta.Y, p.LastDelta.X)
+		p.LastDelta = delta
+	}
+
+	// constrain shooting at fixed intervals
+	if !input.Space || p.Cooldown > 0 {
+		return Bullet{}, false
+	}
+	p.Cooldown = PlayerShootCoolDown
+
+	return Bullet{Position: p.GunPoint(), Rotation: p.Rotation + FacingOffset}, true
+}
+
+// GunPoint returns where the player's bullets spawn.
+// The gun is offset from the player's facing direction.
+func (p *Player) GunPoint() Vector {
+	spawnRotation := p.Rotation + GunPointOffset
+	return Vector{
+		X: p.Position.X + math.Cos(spawnRotation)*BulletSpawnOffset,
+		Y: p.Position.Y + math.Sin(spawnRotation)*BulletSpawnOffset,
+	}
+}
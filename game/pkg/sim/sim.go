@@ -0,0 +1,93 @@
+// Package sim is the deterministic simulation shared by the singleplayer game,
+// the networked client and the server.
+//
+// Given the same state, input and time step, it always produces the same result,
+// which is what lets the client predict its own movement and agree with the server.
+// It must not depend on ebiten, so it can run headless.
+package sim
+
+import (
+	"math"
+	"time"
+)
+
+// Position offsets
+const (
+	FacingOffset      = 90.0 * math.Pi / 180.0
+	GunPointOffset    = 20.0 * math.Pi / 180.0
+	BulletSpawnOffset = 30.0
+)
+
+// Player settings
+const (
+	PlayerSpeedPerSecond  = 200.0 // move x pixels per second
+	PlayerShootCoolDown   = 500 * time.Millisecond
+	InitialPlayerRotation = -FacingOffset
+)
+
+// Bullet settings
+const (
+	BulletSpeedPerSecond = 350.0
+)
+
+// Zombie settings
+const (
+	ZombieMaxSpeedPerSecond = 300
+	ZombieMinSpeedPerSecond = 100
+	ZombieSeparationRadius  = 64.0 // zombies closer than this steer away from each other
+	ZombieSeparationWeight  = 1.5  // how much steering away matters compared to chasing
+	ZombieRepathDistance    = 64.0 // a zombie only looks for a new path once its target has moved this far
+)
+
+type Vector struct {
+	X float64
+	Y float64
+}
+
+func (v Vector) Add(other Vector) Vector {
+	return Vector{v.X + other.X, v.Y + other.Y}
+}
+
+func (v Vector) Sub(other Vector) Vector {
+	return Vector{v.X - other.X, v.Y - other.Y}
+}
+
+func (v Vector) Scale(n float64) Vector {
+	return Vector{v.X * n, v.Y * n}
+}
+
+func (v Vector) Length() float64 {
+	return math.Hypot(v.X, v.Y)
+}
+
+// Reverse returns v pointing the other way.
+func (v Vector) Reverse() Vector {
+	return Vector{-v.X, -v.Y}
+}
+
+// Perpendicular returns v rotated by 90 degrees.
+func (v Vector) Perpendicular() Vector {
+	return Vector{-v.Y, v.X}
+}
+
+func (v Vector) Dot(other Vector) float64 {
+	return v.X*other.X + v.Y*other.Y
+}
+
+// Normalize returns the unit vector of v, or v itself if it has no length.
+func (v Vector) Normalize() Vector {
+	magnitude := v.Length()
+	if magnitude == 0 {
+		return v
+	}
+	return Vector{v.X / magnitude, v.Y / magnitude}
+}
+
+// Input is what a player does during one step.
+type Input struct {
+	W     bool `json:"w"`     // up
+	S     bool `json:"s"`     // down
+	A     bool `json:"a"`     // left
+	D     bool `json:"d"`     // right
+	Space bool `json:"space"` // shoot
+}
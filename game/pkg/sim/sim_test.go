@@ -0,0 +1,90 @@
+package sim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const dt = time.Second / 60
+
+func TestPlayerStep(t *testing.T) {
+	p := NewPlayer(Vector{})
+
+	// no integer division, a second of steps moves the player by its full speed
+	for range 60 {
+		p.Step(Input{D: true}, dt)
+	}
+	assert.InDelta(t, PlayerSpeedPerSecond, p.Position.X, 1e-3)
+	assert.Zero(t, p.Position.Y)
+
+	// moving diagonally is not faster
+	before := p.Position
+	p.Step(Input{W: true, D: true}, dt)
+	assert.InDelta(t, PlayerSpeedPerSecond*dt.Seconds(), p.Position.Sub(before).Length(), 1e-9)
+}
+
+func TestPlayerShoot(t *testing.T) {
+	p := NewPlayer(Vector{})
+
+	_, fired := p.Step(Input{Space: true}, dt)
+	assert.False(t, fired, "cooling down")
+
+	p.Step(Input{}, PlayerShootCoolDown)
+	_, fired = p.Step(Input{Space: true}, dt)
+	assert.False(t, fired, "stepping doesn't cool down")
+
+	p.Cool(PlayerShootCoolDown)
+	b, fired := p.Step(Input{Space: true}, dt)
+	assert.True(t, fired)
+	assert.Equal(t, p.GunPoint(), b.Position)
+	assert.Equal(t, p.Rotation+FacingOffset, b.Rotation)
+
+	_, fired = p.Step(Input{Space: true}, dt)
+	assert.False(t, fired, "cooling down again")
+}
+
+func TestDeterministic(t *testing.T) {
+	inputs := []Input{{W: true}, {W: true, A: true}, {Space: true}, {S: true, D: true, Space: true}, {}}
+
+	run := func() (Player, []Bullet) {
+		p := NewPlayer(Vector{X: 400, Y: 300})
+		var bullets []Bullet
+		for range 50 {
+			for _, input := range inputs {
+				p.Cool(dt)
+				if b, fired := p.Step(input, dt); fired {
+					bullets = append(bullets, b)
+				}
+				for i := range bullets {
+					bullets[i].Step(dt)
+				}
+			}
+		}
+		return p, bullets
+	}
+
+	p1, b1 := run()
+	p2, b2 := run()
+	assert.Equal(t, p1, p2)
+	assert.Equal(t, b1, b2)
+	assert.NotEmpty(t, b1)
+}
+
+func TestBulletStep(t *testing.T) {
+	b := Bullet{Rotation: 0}
+	b.Step(time.Second)
+	assert.InDelta(t, 0, b.Position.X, 1e-9)
+	assert.InDelta(t, -BulletSpeedPerSecond, b.Position.Y, 1e-9)
+}
+
+func TestVector(t *testing.T) {
+	v := Vector{X: 3, Y: 4}
+
+	assert.Equal(t, Vector{X: -3, Y: -4}, v.Reverse())
+	assert.Zero(t, v.Dot(v.Perpendicular()), "perpendicular")
+	assert.Equal(t, 25.0, v.Dot(v))
+	assert.InDelta(t, 1, v.Normalize().Length(), 1e-9)
+	assert.Equal(t, Vector{}, Vector{}.Normalize(), "no length, no direction")
+}
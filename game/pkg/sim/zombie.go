@@ -0,0 +1,53 @@
+package sim
+
+import (
+	"math"
+	"time"
+)
+
+// Zombie is the part of a zombie's state that the simulation changes.
+type Zombie struct {
+	Position Vector
+	Rotation float64
+	Speed    float64 // in pixels per second
+}
+
+// Chase moves the zombie toward target at its speed for a duration of dt, facing it.
+// It steers away from the neighbours closer than ZombieSeparationRadius, so zombies don't stack.
+// Walls are left to the caller, which pushes the zombie out of whatever it walked into.
+func (z *Zombie) Chase(target Vector, neighbours []Vector, dt time.Duration) {
+	step := z.Speed * dt.Seconds()
+	toTarget := target.Sub(z.Position)
+
+	// slow down right next to the target instead of overshooting it
+	chase := toTarget.Normalize()
+	if dist := toTarget.Length(); dist < step {
+		chase = chase.Scale(dist / step)
+	}
+
+	dir := chase.Add(separation(z.Position, neighbours).Scale(ZombieSeparationWeight))
+	if dir.Length() > 1 {
+		dir = dir.Normalize()
+	}
+	z.Position = z.Position.Add(dir.Scale(step))
+
+	if toTarget.X != 0 || toTarget.Y != 0 {
+		z.Rotation = math.Atan2(toTarget.Y, toTarget.X)
+	}
+}
+
+// separation returns the direction away from the neighbours of pos that are too close,
+// each weighted by how close it is. Neighbours right on top of pos are ignored,
+// since there is no telling which way is away from them.
+func separation(pos Vector, neighbours []Vector) Vector {
+	var away Vector
+	for _, n := range neighbours {
+		offset := pos.Sub(n)
+		dist := offset.Length()
+		if dist == 0 || dist >= ZombieSeparationRadius {
+			continue
+		}
+		away = away.Add(offset.Scale((ZombieSeparationRadius - dist) / (ZombieSeparationRadius * dist)))
+	}
+	return away
+}
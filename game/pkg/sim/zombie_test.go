@@ -0,0 +1,45 @@
+package sim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZombieChase(t *testing.T) {
+	z := Zombie{Speed: 100}
+	target := Vector{X: 100}
+
+	z.Chase(target, nil, time.Second/2)
+	assert.InDelta(t, 50, z.Position.X, 1e-9)
+	assert.InDelta(t, 0, z.Rotation, 1e-9)
+
+	// it stops at the target
+	z.Chase(target, nil, time.Second)
+	assert.InDelta(t, 100, z.Position.X, 1e-9)
+	z.Chase(target, nil, time.Second)
+	assert.InDelta(t, 100, z.Position.X, 1e-9)
+}
+
+func TestZombieSeparation(t *testing.T) {
+	target := Vector{X: 1000}
+	a := Zombie{Position: Vector{Y: -10}, Speed: 100}
+	b := Zombie{Position: Vector{Y: 10}, Speed: 100}
+
+	for range 60 {
+		pa, pb := a.Position, b.Position
+		a.Chase(target, []Vector{pb}, dt)
+		b.Chase(target, []Vector{pa}, dt)
+	}
+
+	// both went for the target, but drifted apart
+	assert.Greater(t, a.Position.X, 0.0)
+	assert.Greater(t, b.Position.X, 0.0)
+	assert.Greater(t, b.Position.Sub(a.Position).Length(), 20.0)
+
+	// zombies far enough apart don't mind each other
+	c := Zombie{Speed: 100}
+	c.Chase(target, []Vector{{Y: ZombieSeparationRadius}}, dt)
+	assert.InDelta(t, 0, c.Position.Y, 1e-9)
+}
@@ -5,48 +5,31 @@ import (
 	"math/rand"
 	"time"
 
-	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/livingpool/top-down-shooter/game/assets"
+	"github.com/google/uuid"
+	"github.com/livingpool/top-down-shooter/game/pkg/clock"
 	"github.com/livingpool/top-down-shooter/game/util"
 )
 
 type ZombieSpawner struct {
-	timer   *util.Timer
+	timer   *clock.Timer
 	zombies []*Zombie
 }
 
 // Every duration `d`, spawn `count` zombies
 func NewZombieSpawner(d time.Duration, count int) *ZombieSpawner {
 	return &ZombieSpawner{
-		timer:   util.NewTimer(d),
+		timer:   clock.NewTimer(d),
 		zombies: make([]*Zombie, count),
 	}
 }
 
-func (zs *ZombieSpawner) Update() {
-	zs.timer.Update()
+func (zs *ZombieSpawner) Update(dt time.Duration) {
+	zs.timer.Update(dt)
 	if zs.timer.IsReady() {
 		zs.timer.Reset()
 	}
 }
 
-func (zs *ZombieSpawner) Draw(screen *ebiten.Image) {
-	for _, z := range zs.zombies {
-		bounds := z.sprite.Bounds()
-		halfW := float64(bounds.Dx()) / 2
-		halfH := float64(bounds.Dy()) / 2
-
-		op := &ebiten.DrawImageOptions{}
-		op.GeoM.Translate(-halfW, -halfH)
-		op.GeoM.Rotate(z.rotation)
-		op.GeoM.Translate(halfW, halfH)
-
-		op.GeoM.Translate(z.position.X, z.position.Y)
-
-		screen.DrawImage(z.sprite, op)
-	}
-}
-
 // Generates a random position at the edge of the screen (a ring).
 // Targets
 func randPosition(screenWidth, screenHeight float64, target util.Vector) util.Vector {
@@ -66,20 +49,22 @@ func randPosition(screenWidth, screenHeight float64, target util.Vector) util.Ve
 }
 
 // Generates a random velocity
-func randVelocity()
+func randVelocity() float64 {
+	max, min := util.ZombieMaxSpeedPerSecond, util.ZombieMinSpeedPerSecond
+	return float64(rand.Intn(max-min) + min)
+}
 
 type Zombie struct {
-	object util.GameObject
+	ID     uuid.UUID
+	Object util.GameObject
 }
 
 func NewZombie() *Zombie {
-	sprite := assets.Zombie1HoldSprite
-
 	return &Zombie{
-		object: util.GameObject{
+		ID: uuid.New(),
+		Object: util.GameObject{
 			Vector:   util.Vector{},
 			Rotation: -util.FacingOffset,
-			Sprite:   sprite,
 		},
 	}
 }
@@ -88,12 +73,10 @@ func (z *Zombie) Update() {
 }
 
 func (z *Zombie) Collider() util.Rect {
-	bounds := z.object.Sprite.Bounds()
-
 	return util.NewRect(
-		z.object.Vector.X,
-		z.object.Vector.Y,
-		float64(bounds.Dx()),
-		float64(bounds.Dy()),
+		z.Object.Vector.X,
+		z.Object.Vector.Y,
+		util.ZombieWidth,
+		util.ZombieHeight,
 	)
 }
@@ -0,0 +1,127 @@
+package state
+
+import (
+	"github.com/livingpool/top-down-shooter/game/util"
+)
+
+// Delta compression of snapshots.
+//
+// Every snapshot of a game has a Seq. Clients acknowledge the snapshots they have applied,
+// and the server only sends what changed since the last one a client acknowledged, its baseline.
+// Both sides keep a History of recent full snapshots, so a delta can always be diffed and patched
+// against the same baseline. A snapshot with no Baseline is a full snapshot.
+
+// Diff returns the entities of update that were created or changed since baseline,
+// and the ids of those that were removed.
+func Diff(baseline, update ServerUpdate) ServerUpdate {
+	delta := update
+	delta.Baseline = baseline.Seq
+	delta.Removed = nil
+
+	delta.Players, delta.Removed = diffEntities(baseline.Players, update.Players, delta.Removed, func(p PlayerState) string { return p.ID })
+	delta.Bullets, delta.Removed = diffEntities(baseline.Bullets, update.Bullets, delta.Removed, func(b BulletState) string { return b.ID })
+	delta.Zombies, delta.Removed = diffEntities(baseline.Zombies, update.Zombies, delta.Removed, func(z ZombieState) string { return z.ID })
+
+	return delta
+}
+
+// Patch applies a delta to the baseline it was diffed against, and returns the full snapshot.
+func Patch(baseline, delta ServerUpdate) ServerUpdate {
+	removed := make(map[string]bool, len(delta.Removed))
+	for _, id := range delta.Removed {
+		removed[id] = true
+	}
+
+	update := delta
+	update.Baseline = 0
+	update.Removed = nil
+
+	update.Players = patchEntities(baseline.Players, delta.Players, removed, func(p PlayerState) string { return p.ID })
+	update.Bullets = patchEntities(baseline.Bullets, delta.Bullets, removed, func(b BulletState) string { return b.ID })
+	update.Zombies = patchEntities(baseline.Zombies, delta.Zombies, removed, func(z ZombieState) string { return z.ID })
+
+	return update
+}
+
+func diffEntities[T comparable](baseline, current []T, removed []string, id func(T) string) ([]T, []string) {
+	previous := make(map[string]T, len(baseline))
+	for _, e := range baseline {
+		previous[id(e)] = e
+	}
+
+	changed := make([]T, 0)
+	for _, e := range current {
+		if prev, exists := previous[id(e)]; !exists || prev != e {
+			changed = append(changed, e)
+		}
+		delete(previous, id(e))
+	}
+
+	// whatever is left in previous is gone
+	for _, e := range baseline {
+		if _, exists := previous[id(e)]; exists {
+			removed = append(removed, id(e))
+		}
+	}
+
+	return changed, removed
+}
+
+func patchEntities[T any](baseline, changed []T, removed map[string]bool, id func(T) string) []T {
+	updated := make(map[string]T, len(changed))
+	for _, e := range changed {
+		updated[id(e)] = e
+	}
+
+	entities := make([]T, 0, len(baseline)+len(changed))
+	for _, e := range baseline {
+		if removed[id(e)] {
+			continue
+		}
+		if u, exists := updated[id(e)]; exists {
+			e = u
+			delete(updated, id(e))
+		}
+		entities = append(entities, e)
+	}
+
+	// the rest were created since the baseline
+	for _, e := range changed {
+		if _, exists := updated[id(e)]; exists {
+			entities = append(entities, e)
+		}
+	}
+
+	return entities
+}
+
+// History keeps the last util.SnapshotHistorySize full snapshots, to be used as baselines.
+// It is not safe for concurrent use.
+type History struct {
+	snapshots []ServerUpdate
+}
+
+func NewHistory() *History {
+	return &History{
+		snapshots: make([]ServerUpdate, 0, util.SnapshotHistorySize),
+	}
+}
+
+// Add stores a full snapshot, dropping the oldest one if the history is full.
+// Snapshots must be added in order of Seq.
+func (h *History) Add(update ServerUpdate) {
+	if len(h.snapshots) == util.SnapshotHistorySize {
+		h.snapshots = append(h.snapshots[:0], h.snapshots[1:]...)
+	}
+	h.snapshots = append(h.snapshots, update)
+}
+
+// Get returns the snapshot with the given seq, if it is still in the history.
+func (h *History) Get(seq int) (ServerUpdate, bool) {
+	for i := len(h.snapshots) - 1; i >= 0; i-- {
+		if h.snapshots[i].Seq == seq {
+			return h.snapshots[i], true
+		}
+	}
+	return ServerUpdate{}, false
+}
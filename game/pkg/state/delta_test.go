@@ -0,0 +1,47 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/livingpool/top-down-shooter/game/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffAndPatch(t *testing.T) {
+	baseline := ServerUpdate{
+		Seq:     1,
+		Players: []PlayerState{{ID: "a", X: 1}, {ID: "b", X: 2}},
+		Bullets: []BulletState{{ID: "c", X: 3}},
+		Zombies: []ZombieState{{ID: "d", X: 4}},
+	}
+	update := ServerUpdate{
+		Seq:     3,
+		Players: []PlayerState{{ID: "a", X: 1}, {ID: "b", X: 5}},
+		Bullets: []BulletState{{ID: "e", X: 6}},
+		Zombies: []ZombieState{{ID: "d", X: 4}},
+	}
+
+	delta := Diff(baseline, update)
+	assert.Equal(t, 1, delta.Baseline)
+	assert.Equal(t, []PlayerState{{ID: "b", X: 5}}, delta.Players, "only changed players are sent")
+	assert.Equal(t, []BulletState{{ID: "e", X: 6}}, delta.Bullets, "new bullets are sent")
+	assert.Empty(t, delta.Zombies)
+	assert.Equal(t, []string{"c"}, delta.Removed)
+
+	assert.Equal(t, update, Patch(baseline, delta))
+}
+
+func TestHistory(t *testing.T) {
+	h := NewHistory()
+	for seq := 1; seq <= util.SnapshotHistorySize+1; seq++ {
+		h.Add(ServerUpdate{Seq: seq})
+	}
+
+	_, exists := h.Get(1)
+	assert.False(t, exists, "the oldest snapshot is dropped")
+
+	update, exists := h.Get(util.SnapshotHistorySize + 1)
+	require.True(t, exists)
+	assert.Equal(t, util.SnapshotHistorySize+1, update.Seq)
+}
@@ -12,13 +12,102 @@ type GameState struct {
 	Zombies []*spawner.Zombie
 }
 
+// Msg is the envelope of every message sent over the websocket.
+// Type tells what the payload is, e.g. a ServerUpdate for util.MsgTypeSnapshot.
 type Msg struct {
+	Type    string `json:"type"`
+	Payload any    `json:"payload"`
 }
 
+// Ping asks the other end to reply with a Pong.
 type Ping struct {
+	Seq    int `json:"seq"`
+	SentAt int `json:"sent_at"` // sender's time in unix ms
 }
 
+// Pong replies to a Ping.
+type Pong struct {
+	Seq       int `json:"seq"`
+	SentAt    int `json:"sent_at"`    // copied from the ping
+	RepliedAt int `json:"replied_at"` // replier's time in unix ms
+}
+
+// Chat is a text message from a player, to everyone in the game or only to its team.
+type Chat struct {
+	PlayerId  string `json:"player_id"`
+	Name      string `json:"name"`
+	Channel   string `json:"channel"` // util.ChatChannelAll or util.ChatChannelTeam
+	Text      string `json:"text"`
+	TimeStamp int    `json:"timestamp"`
+}
+
+// Presence tells the clients that a player joined or left the game,
+// i.e. connected or disconnected.
+type Presence struct {
+	PlayerId string `json:"player_id"`
+	Name     string `json:"name"`
+}
+
+// ProtocolError tells the other end that one of its messages was rejected.
+type ProtocolError struct {
+	Message string `json:"message"`
+}
+
+// ServerUpdate is the authoritative state of a game, published by the server at fixed intervals.
+// It is either a full snapshot, or a delta against a previous snapshot, see Diff.
 type ServerUpdate struct {
+	GameId    string        `json:"game_id"`
+	Seq       int           `json:"seq"`                // increases with every snapshot of the game
+	Baseline  int           `json:"baseline,omitempty"` // seq of the snapshot this is a delta against, 0 if it is full
+	TimeStamp int           `json:"timestamp"`          // server time in unix ms when the snapshot was taken
+	Phase     string        `json:"phase"`
+	Players   []PlayerState `json:"players"`
+	Bullets   []BulletState `json:"bullets"`
+	Zombies   []ZombieState `json:"zombies"`
+	Removed   []string      `json:"removed,omitempty"` // ids of the entities removed since the baseline
+}
+
+const (
+	EventPhaseChanged = "phase_changed"
+)
+
+// GameEvent tells the clients that something happened in the game, e.g. its phase changed.
+type GameEvent struct {
+	Event     string `json:"event"` // what happened, e.g. EventPhaseChanged
+	GameId    string `json:"game_id"`
+	Phase     string `json:"phase"`
+	PrevPhase string `json:"prev_phase"`
+	EndsAt    int    `json:"ends_at,omitempty"` // server time in unix ms when the phase times out, if it does
+	TimeStamp int    `json:"timestamp"`
+}
+
+type PlayerState struct {
+	ID           string  `json:"id"`
+	Name         string  `json:"name"`
+	Team         int     `json:"team"`
+	X            float64 `json:"x"`
+	Y            float64 `json:"y"`
+	Rotation     float64 `json:"rotation"`
+	Health       int     `json:"health"`
+	Ammo         int     `json:"ammo"`
+	LastInputSeq int     `json:"last_input_seq"` // the last input the server has simulated, i.e., acknowledged
+	RTT          int     `json:"rtt"`            // in ms, as measured by the server
+	Jitter       int     `json:"jitter"`         // in ms
+	ClockOffset  int     `json:"clock_offset"`   // in ms, how far the player's clock is ahead of the server's
+}
+
+type BulletState struct {
+	ID       string  `json:"id"`
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	Rotation float64 `json:"rotation"`
+}
+
+type ZombieState struct {
+	ID       string  `json:"id"`
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	Rotation float64 `json:"rotation"`
 }
 
 type ClientUpdate struct {
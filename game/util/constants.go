@@ -1,28 +1,65 @@
 package util
 
 import (
-	"math"
 	"time"
+
+	"github.com/livingpool/top-down-shooter/game/pkg/sim"
 )
 
 // Websocket settings
 const (
-	ClientUpdateBufferSize = 16
+	ClientUpdateBufferSize = 16 // also bounds the messages queued for each subscriber on the server
+	InputQueueSize         = 32 // inputs of a player waiting for the next physics update
+	ServerUpdateBufferSize = 16
+	SnapshotHistorySize    = 32 // snapshots older than this are never used as a delta baseline
+
+	DefaultReconnectGracePeriod = 30 * time.Second
+	DefaultIdleGameTimeout      = 2 * time.Minute
+	ReapPeriod                  = time.Second
+	PingPeriod                  = time.Second // how often the server measures the latency of each player
+	WriteTimeout                = 5 * time.Second
+	SessionTokenTTL             = 12 * time.Hour
+)
+
+// Anti-cheat settings
+const (
+	MaxInputBurst       = 10 // inputs a player can submit at once, e.g. after a lag spike
+	MaxInputClockSkew   = time.Second
+	MaxInputViolations  = 30          // players are kicked once this many of their inputs are rejected
+	InputViolationDecay = time.Second // one violation is forgiven every this long, so only repeat offenders are kicked
 )
 
 // Game settings
 const (
-	ScreenWidth         = 800
-	ScreenHeight        = 600
-	ServerPhysicsPeriod = 15 * time.Millisecond
-	ServerUpdatePeriod  = 45 * time.Millisecond
+	ScreenWidth          = 800
+	ScreenHeight         = 600
+	ServerPhysicsPeriod  = 15 * time.Millisecond
+	ServerUpdatePeriod   = 45 * time.Millisecond
+	ClientInputPeriod    = time.Second / 60       // each client update moves the player for this long
+	InterpolationDelay   = 2 * ServerUpdatePeriod // remote entities are rendered this far in the past
+	DefaultRewindWindow  = 200 * time.Millisecond // lag compensation never rewinds further than this
+	MaxPlayersPerGame    = 4
+	MaxSpectatorsPerGame = 8
+	TeamCount            = 2
+	CountdownDuration    = 3 * time.Second
+	RoundDuration        = 3 * time.Minute
+	RoundOverDuration    = 5 * time.Second
+)
+
+// Chat settings
+const (
+	MaxChatLength     = 200 // in characters
+	ChatRateLimit     = 5   // messages a player can send per ChatRatePeriod
+	ChatRatePeriod    = 10 * time.Second
+	ChatHistorySize   = 6 // messages shown in the client's overlay
+	ChatDisplayPeriod = 10 * time.Second
 )
 
-// Position offsets
+// Position offsets, see sim
 const (
-	FacingOffset      = 90.0 * math.Pi / 180.0
-	GunPointOffset    = 20.0 * math.Pi / 180.0
-	BulletSpawnOffset = 30.0
+	FacingOffset      = sim.FacingOffset
+	GunPointOffset    = sim.GunPointOffset
+	BulletSpawnOffset = sim.BulletSpawnOffset
 )
 
 // Initial player states
@@ -31,7 +68,8 @@ const (
 	InitialPlayerAmmo     = 10
 	InitialPlayerX        = ScreenWidth / 2
 	InitialPlayerY        = ScreenHeight / 2
-	InitialPlayerRotation = -FacingOffset
+	InitialPlayerRotation = sim.InitialPlayerRotation
+	PlayerSpawnSpacing    = 64.0 // players never spawn closer than this to each other
 )
 
 // All the different sprites
@@ -46,13 +84,31 @@ const (
 	HumanoidStateStand
 )
 
-// Player settings
+// Collider sizes, in pixels. They match the sprites the client draws,
+// but are declared here so the server doesn't need to load any.
+const (
+	PlayerWidth  = 49
+	PlayerHeight = 43
+	BulletWidth  = 64
+	BulletHeight = 64
+	ZombieWidth  = 35
+	ZombieHeight = 43
+)
+
+// Player settings, see sim
+const (
+	PlayerSpeedPerSecond = sim.PlayerSpeedPerSecond
+	PlayerShootCoolDown  = sim.PlayerShootCoolDown
+)
+
+// Bullet settings, see sim
 const (
-	PlayerSpeedPerSecond = 200 // move x pixels per second
-	PlayerShootCoolDown  = 500 * time.Millisecond
+	BulletSpeedPerSecond = sim.BulletSpeedPerSecond
+	BulletLifetime       = 3 * time.Second // bullets that hit nothing are removed after this long
 )
 
-// Bullet settings
+// Zombie spawner settings, see sim
 const (
-	BulletSpeedPerSecond = 350.0
+	ZombieMaxSpeedPerSecond = sim.ZombieMaxSpeedPerSecond
+	ZombieMinSpeedPerSecond = sim.ZombieMinSpeedPerSecond
 )
@@ -1,22 +1,60 @@
 package util
 
+import "github.com/livingpool/top-down-shooter/game/pkg/sim"
+
 type CreatePlayerResp struct {
 	PlayerId string `json:"player_id"`
 	GameId   string `json:"game_id"`
+	Token    string `json:"token"` // the session token to /join with
 }
 
 type ClientUpdate struct {
 	PlayerId  string   `json:"player_id"`
-	Type      string   `json:"type"`
 	Keys      KeyPress `json:"keys"`
 	Seq       int      `json:"seq"`
 	TimeStamp int      `json:"timestamp"`
 }
 
-type KeyPress struct {
-	W     bool `json:"w"`     // up
-	S     bool `json:"s"`     // down
-	A     bool `json:"a"`     // left
-	D     bool `json:"d"`     // right
-	Space bool `json:"space"` // shoot
+// SnapshotAck tells the server the last snapshot the client has applied,
+// so the next ones can be sent as deltas against it.
+type SnapshotAck struct {
+	Seq int `json:"seq"`
+}
+
+// KeyPress is the simulation's input, sent as is by the client.
+type KeyPress = sim.Input
+
+// Chat channels
+const (
+	ChatChannelAll  = "all"
+	ChatChannelTeam = "team"
+)
+
+// Types of the messages sent over the websocket, see state.Msg
+const (
+	MsgTypeInput    = "input"    // ClientUpdate, client to server
+	MsgTypeAck      = "ack"      // SnapshotAck, client to server
+	MsgTypeSnapshot = "snapshot" // state.ServerUpdate, server to client
+	MsgTypeEvent    = "event"    // state.GameEvent, server to client
+	MsgTypePing     = "ping"     // state.Ping, either way
+	MsgTypePong     = "pong"     // state.Pong, either way
+	MsgTypeChat     = "chat"     // state.Chat, either way
+	MsgTypeJoin     = "join"     // state.Presence, server to client
+	MsgTypeLeave    = "leave"    // state.Presence, server to client
+	MsgTypeError    = "error"    // state.ProtocolError, either way
+)
+
+type GameInfo struct {
+	GameId      string       `json:"game_id"`
+	Status      string       `json:"status"` // the game's phase
+	PlayerCount int          `json:"player_count"`
+	MaxPlayers  int          `json:"max_players"`
+	Spectators  int          `json:"spectators"`
+	Players     []PlayerInfo `json:"players,omitempty"` // only included in a game's details
+}
+
+type PlayerInfo struct {
+	PlayerId  string `json:"player_id"`
+	Name      string `json:"name"`
+	Connected bool   `json:"connected"`
 }
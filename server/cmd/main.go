@@ -10,10 +10,19 @@ import (
 	"os/signal"
 	"time"
 
+	"github.com/livingpool/top-down-shooter/game/util"
 	"github.com/livingpool/top-down-shooter/server/server"
 )
 
-var addr = flag.String("addr", ":42069", "game server address")
+var (
+	addr   = flag.String("addr", ":42069", "game server address")
+	rewind = flag.Duration("rewind", util.DefaultRewindWindow, "max lag compensation for bullet hits, 0 to disable")
+	grace  = flag.Duration("grace", util.DefaultReconnectGracePeriod, "how long a disconnected player can take to reconnect")
+	idle   = flag.Duration("idle", util.DefaultIdleGameTimeout, "how long a game is kept without connected players")
+	slow   = flag.String("slow", "drop", "what to do with clients that can't keep up: drop their stale snapshots, or disconnect them")
+	delay  = flag.Duration("spectator-delay", 0, "how far behind the players the spectators watch")
+	secret = flag.String("secret", os.Getenv("SESSION_SECRET"), "secret to sign session tokens with, a random one if empty")
+)
 
 func main() {
 	flag.Parse()
@@ -24,8 +33,31 @@ func main() {
 	}
 	log.Printf("listening on ws://%v\n", listener.Addr())
 
+	slowClients := server.DropStaleSnapshots
+	switch *slow {
+	case "drop":
+	case "disconnect":
+		slowClients = server.DisconnectSlowClients
+	default:
+		log.Fatalf("unknown -slow policy: %v\n", *slow)
+	}
+
+	opts := []server.Option{
+		server.WithRewindWindow(*rewind),
+		server.WithReconnectGracePeriod(*grace),
+		server.WithIdleTimeout(*idle),
+		server.WithSlowClientPolicy(slowClients),
+		server.WithSpectatorDelay(*delay),
+	}
+	if *secret != "" {
+		opts = append(opts, server.WithSessionSecret([]byte(*secret)))
+	}
+
+	gs := server.NewGameServer(opts...)
+	defer gs.Close()
+
 	server := &http.Server{
-		Handler:      server.NewGameServer(),
+		Handler:      gs,
 		Addr:         *addr,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/livingpool/top-down-shooter/game/pkg/player"
+	"github.com/livingpool/top-down-shooter/game/util"
+)
+
+// inputGuard validates a player's inputs before they are queued for simulation.
+//
+// Inputs must come in order of Seq, and a player can't submit inputs faster than its client samples them:
+// every physics tick refills the player's budget with the inputs a client samples in that time,
+// and the budget can only grow up to util.MaxInputBurst so a lagging client can catch up, but not much more.
+// Otherwise a modified client could move faster by sending more inputs.
+// Inputs over the budget are dropped, but not counted as violations: an honest client catching up after a lag spike sends them too.
+type inputGuard struct {
+	lastSeq    int     // seq of the last accepted input
+	budget     float64 // how many inputs the player can submit right now
+	violations float64 // how many inputs were rejected, forgiven over time, see util.InputViolationDecay
+}
+
+var errInputRate = errors.New("input exceeds the input rate")
+
+func newInputGuard() *inputGuard {
+	return &inputGuard{budget: util.MaxInputBurst}
+}
+
+// refill adds the inputs a client samples in dt to the budget, and forgives the violations of dt.
+func (ig *inputGuard) refill(dt time.Duration) {
+	ig.budget = min(ig.budget+float64(dt)/float64(util.ClientInputPeriod), util.MaxInputBurst)
+	ig.violations = max(ig.violations-float64(dt)/float64(util.InputViolationDecay), 0)
+}
+
+// validateInput checks an input sent over the connection of p, and takes it out of p's budget.
+// The caller must hold r.mutex.
+func validateInput(r *room, p *player.Player, input util.ClientUpdate, now time.Time) error {
+	ig, exists := r.guards[p.ID]
+	if !exists {
+		ig = newInputGuard()
+		r.guards[p.ID] = ig
+	}
+
+	if input.PlayerId != p.ID.String() {
+		return fmt.Errorf("input for player %v sent by player %v", input.PlayerId, p.ID)
+	}
+	if input.Seq <= ig.lastSeq {
+		return fmt.Errorf("input %v is out of order or duplicated, last one was %v", input.Seq, ig.lastSeq)
+	}
+	// the player's clock can only be trusted once it has been measured
+	if p.Latency.Samples > 0 && input.TimeStamp != 0 {
+		sentAt := p.Latency.LocalTime(time.UnixMilli(int64(input.TimeStamp)))
+		if skew := sentAt.Sub(now); skew > util.MaxInputClockSkew {
+			return fmt.Errorf("input %v is %v in the future", input.Seq, skew)
+		}
+	}
+	if ig.budget < 1 {
+		return fmt.Errorf("input %v: %w", input.Seq, errInputRate)
+	}
+
+	ig.lastSeq = input.Seq
+	ig.budget--
+
+	return nil
+}
+
+// resetInputs lets a player that has just connected count its inputs from the start again, as its new client does.
+// Its violations are kept, so reconnecting doesn't clear a cheater's record. The caller must hold r.mutex.
+func (r *room) resetInputs(p *player.Player) {
+	if ig, exists := r.guards[p.ID]; exists {
+		ig.lastSeq = 0
+	}
+	p.ResetInputs()
+}
+
+// reject counts a violation of the player, and reports whether it has reached util.MaxInputViolations.
+// Inputs over the rate are only dropped. The caller must hold r.mutex.
+func (gs *GameServer) reject(r *room, p *player.Player, err error) bool {
+	if errors.Is(err, errInputRate) {
+		gs.logger.Debug("dropped input", "game", r.game.ID, "player", p.ID, "err", err)
+		return false
+	}
+
+	ig := r.guards[p.ID]
+	ig.violations++
+	gs.logger.Warn("rejected input", "game", r.game.ID, "player", p.ID, "violations", ig.violations, "err", err)
+
+	return ig.violations >= util.MaxInputViolations
+}
+
+// kick removes the player from its game and closes its connection, so it can't rejoin.
+func (gs *GameServer) kick(r *room, p *player.Player) {
+	r.mutex.Lock()
+	r.removePlayer(p.ID)
+	conn := p.Conn
+	r.mutex.Unlock()
+
+	gs.logger.Warn("player kicked", "game", r.game.ID, "player", p.ID)
+
+	if conn != nil {
+		conn.Close(websocket.StatusPolicyViolation, "too many invalid inputs")
+	}
+}
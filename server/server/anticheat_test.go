@@ -0,0 +1,86 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/livingpool/top-down-shooter/game/game"
+	"github.com/livingpool/top-down-shooter/game/pkg/player"
+	"github.com/livingpool/top-down-shooter/game/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveClientUpdate(t *testing.T) {
+	gs := NewGameServer()
+	defer gs.Close()
+
+	room := newRoom(game.NewGame(true))
+	require.NoError(t, gs.addGame(room))
+
+	tim, steven := player.NewPlayer("tim"), player.NewPlayer("steven")
+	require.NoError(t, gs.addPlayer(tim, room))
+	require.NoError(t, gs.addPlayer(steven, room))
+
+	input := func(p *player.Player, seq int) util.ClientUpdate {
+		return util.ClientUpdate{PlayerId: p.ID.String(), Seq: seq}
+	}
+
+	require.NoError(t, gs.saveClientUpdate(room, tim, input(tim, 1)))
+	assert.Error(t, gs.saveClientUpdate(room, tim, input(tim, 1)), "duplicated")
+	assert.Error(t, gs.saveClientUpdate(room, tim, input(steven, 2)), "someone else's input")
+
+	// the budget runs out, until the next physics tick refills it
+	for seq := 2; seq <= util.MaxInputBurst; seq++ {
+		require.NoError(t, gs.saveClientUpdate(room, tim, input(tim, seq)))
+	}
+	assert.Error(t, gs.saveClientUpdate(room, tim, input(tim, util.MaxInputBurst+1)), "too fast")
+	room.guards[tim.ID].refill(util.ClientInputPeriod)
+	assert.NoError(t, gs.saveClientUpdate(room, tim, input(tim, util.MaxInputBurst+1)))
+
+	// inputs from the future, once the player's clock is known
+	tim.Latency.Sample(time.Now(), time.Now(), time.Now())
+	future := input(tim, util.MaxInputBurst+2)
+	future.TimeStamp = int(time.Now().Add(time.Minute).UnixMilli())
+	room.guards[tim.ID].refill(util.ClientInputPeriod)
+	assert.Error(t, gs.saveClientUpdate(room, tim, future))
+
+	// repeat offenders are kicked
+	for range util.MaxInputViolations {
+		gs.saveClientUpdate(room, steven, input(steven, 0))
+	}
+	assert.NotContains(t, room.game.Players, steven.ID)
+	assert.Contains(t, room.game.Players, tim.ID)
+}
+
+func TestLagSpikesAreNotCheating(t *testing.T) {
+	gs := NewGameServer()
+	defer gs.Close()
+
+	room := newRoom(game.NewGame(true))
+	require.NoError(t, gs.addGame(room))
+
+	tim := player.NewPlayer("tim")
+	require.NoError(t, gs.addPlayer(tim, room))
+
+	// every couple of seconds, the connection stalls and the inputs sampled meanwhile arrive at once,
+	// along with the odd duplicate. That's more violations than util.MaxInputViolations over time, but not at once
+	input := func(seq int) util.ClientUpdate {
+		return util.ClientUpdate{PlayerId: tim.ID.String(), Seq: seq}
+	}
+
+	seq, spike := 0, 2*time.Second
+	for range 2 * util.MaxInputViolations {
+		accepted := 0
+		for range spike / util.ClientInputPeriod {
+			seq++
+			if gs.saveClientUpdate(room, tim, input(seq)) == nil {
+				accepted = seq
+			}
+		}
+		assert.Error(t, gs.saveClientUpdate(room, tim, input(accepted)), "duplicated")
+		require.Contains(t, room.game.Players, tim.ID)
+
+		room.guards[tim.ID].refill(spike)
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/livingpool/top-down-shooter/game/pkg/player"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
+	"github.com/livingpool/top-down-shooter/game/util"
+)
+
+// rateLimiter allows at most limit events in any period.
+type rateLimiter struct {
+	times  []time.Time // of the events allowed within the last period
+	limit  int
+	period time.Duration
+}
+
+func newRateLimiter(limit int, period time.Duration) *rateLimiter {
+	return &rateLimiter{
+		times:  make([]time.Time, 0, limit),
+		limit:  limit,
+		period: period,
+	}
+}
+
+// allow reports whether an event can happen at now, and records it if so.
+func (rl *rateLimiter) allow(now time.Time) bool {
+	i := 0
+	for i < len(rl.times) && now.Sub(rl.times[i]) >= rl.period {
+		i++
+	}
+	rl.times = rl.times[i:]
+
+	if len(rl.times) >= rl.limit {
+		return false
+	}
+	rl.times = append(rl.times, now)
+	return true
+}
+
+// sendChat broadcasts a chat message from the player p to its channel:
+// every player and spectator of the game, or only the players of p's team.
+// Messages that are empty, too long, or sent too often are rejected.
+func (gs *GameServer) sendChat(r *room, p *player.Player, chat state.Chat, now time.Time) error {
+	chat.Text = strings.TrimSpace(chat.Text)
+	if chat.Text == "" {
+		return fmt.Errorf("chat message is empty")
+	}
+	if utf8.RuneCountInString(chat.Text) > util.MaxChatLength {
+		return fmt.Errorf("chat message is longer than %v characters", util.MaxChatLength)
+	}
+	if chat.Channel == "" {
+		chat.Channel = util.ChatChannelAll
+	}
+	if chat.Channel != util.ChatChannelAll && chat.Channel != util.ChatChannelTeam {
+		return fmt.Errorf("unknown chat channel: %q", chat.Channel)
+	}
+
+	// the sender is whoever owns the connection, regardless of what the message says
+	chat.PlayerId = p.ID.String()
+	chat.Name = p.Name
+	chat.TimeStamp = int(now.UnixMilli())
+	msg := state.Msg{Type: util.MsgTypeChat, Payload: chat}
+
+	r.mutex.Lock()
+	limiter, exists := r.chatLimits[p.ID]
+	if !exists {
+		limiter = newRateLimiter(util.ChatRateLimit, util.ChatRatePeriod)
+		r.chatLimits[p.ID] = limiter
+	}
+	if !limiter.allow(now) {
+		r.mutex.Unlock()
+		return fmt.Errorf("too many chat messages, at most %v every %v", util.ChatRateLimit, util.ChatRatePeriod)
+	}
+
+	var subs []subscriber
+	if chat.Channel == util.ChatChannelTeam {
+		subs = r.teamSubscribers(p.Team)
+	} else {
+		subs = r.subscribers()
+		r.toSpectators(msg, now)
+	}
+	r.mutex.Unlock()
+
+	gs.broadcast(subs, msg)
+	return nil
+}
+
+// teamSubscribers returns every player of the team that is connected. The caller must hold r.mutex.
+func (r *room) teamSubscribers(team int) []subscriber {
+	subs := make([]subscriber, 0, len(r.game.Players))
+	for _, p := range r.game.Players {
+		if sub, exists := r.conns[p.Conn]; exists && p.Team == team {
+			subs = append(subs, *sub)
+		}
+	}
+	return subs
+}
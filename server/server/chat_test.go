@@ -0,0 +1,60 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/livingpool/top-down-shooter/game/game"
+	"github.com/livingpool/top-down-shooter/game/pkg/clock"
+	"github.com/livingpool/top-down-shooter/game/pkg/player"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
+	"github.com/livingpool/top-down-shooter/game/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	rl := newRateLimiter(2, time.Second)
+	clk := clock.NewManual(time.Unix(0, 0))
+
+	assert.True(t, rl.allow(clk.Now()))
+	clk.Advance(100 * time.Millisecond)
+	assert.True(t, rl.allow(clk.Now()))
+	clk.Advance(100 * time.Millisecond)
+	assert.False(t, rl.allow(clk.Now()))
+
+	// the first one has left the window
+	clk.Advance(800 * time.Millisecond)
+	assert.True(t, rl.allow(clk.Now()))
+	assert.False(t, rl.allow(clk.Now()))
+}
+
+func TestSendChat(t *testing.T) {
+	clk := clock.NewManual(time.Unix(0, 0))
+	gs := NewGameServer(WithClock(clk))
+	defer gs.Close()
+
+	g := game.NewGame(true)
+	g.Clock = clk
+	room := newRoom(g)
+	require.NoError(t, gs.addGame(room))
+
+	tim := player.NewPlayer("tim")
+	require.NoError(t, gs.addPlayer(tim, room))
+
+	chat := func(channel, text string) state.Chat {
+		return state.Chat{Channel: channel, Text: text}
+	}
+
+	assert.Error(t, gs.sendChat(room, tim, chat(util.ChatChannelAll, "   "), clk.Now()), "empty")
+	assert.Error(t, gs.sendChat(room, tim, chat(util.ChatChannelAll, strings.Repeat("a", util.MaxChatLength+1)), clk.Now()), "too long")
+	assert.Error(t, gs.sendChat(room, tim, chat("enemies", "hi"), clk.Now()), "unknown channel")
+
+	for range util.ChatRateLimit {
+		require.NoError(t, gs.sendChat(room, tim, chat(util.ChatChannelTeam, "hi"), clk.Now()))
+	}
+	assert.Error(t, gs.sendChat(room, tim, chat(util.ChatChannelAll, "hi"), clk.Now()), "too often")
+	clk.Advance(util.ChatRatePeriod)
+	assert.NoError(t, gs.sendChat(room, tim, chat("", "hi"), clk.Now()))
+}
@@ -1,53 +1,256 @@
 package server
 
 import (
-	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/coder/websocket"
 	"github.com/google/uuid"
 	"github.com/livingpool/top-down-shooter/game/game"
+	"github.com/livingpool/top-down-shooter/game/pkg/player"
+	"github.com/livingpool/top-down-shooter/game/pkg/protocol"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
 	"github.com/livingpool/top-down-shooter/game/util"
 )
 
-// saveClientUpdate takes a player's keystrokes and store them in the appropriate game world
-func (gs *GameServer) saveClientUpdate(game *game.Game, update util.ClientUpdate) error {
-	id, err := uuid.Parse(update.PlayerId)
+// room is a game hosted by the server.
+// Its game is simulated by its own goroutine, so every access to it must hold mutex.
+type room struct {
+	game      *game.Game
+	mutex     *sync.Mutex
+	done      chan struct{} // closed when the room shuts down
+	closeOnce *sync.Once
+
+	lastActiveAt time.Time // the last time any player was connected, see GameServer.reap
+
+	conns       map[*websocket.Conn]*subscriber // every open connection to the room
+	guards      map[uuid.UUID]*inputGuard       // validates the inputs of each player
+	chatLimits  map[uuid.UUID]*rateLimiter      // how often each player can chat
+	spectators  map[*websocket.Conn]*subscriber // read-only viewers, see GameServer.spectate
+	feed        []delayedMsg                    // messages held back for the spectators
+	snapshotSeq int                             // seq of the last snapshot taken
+	snapshots   *state.History                  // the last snapshots taken, to diff the next ones against
+}
+
+// subscriber is a connection, how to encode messages for it, the queue of messages to write to it,
+// and the last snapshot it has acknowledged.
+type subscriber struct {
+	conn  *websocket.Conn
+	codec protocol.Codec
+	out   *outbox
+	ack   int
+}
+
+func newRoom(game *game.Game) *room {
+	return &room{
+		game:      game,
+		mutex:     &sync.Mutex{},
+		done:      make(chan struct{}),
+		closeOnce: &sync.Once{},
+
+		lastActiveAt: game.Clock.Now(),
+
+		conns:      make(map[*websocket.Conn]*subscriber),
+		guards:     make(map[uuid.UUID]*inputGuard),
+		chatLimits: make(map[uuid.UUID]*rateLimiter),
+		spectators: make(map[*websocket.Conn]*subscriber),
+		snapshots:  state.NewHistory(),
+	}
+}
+
+// close stops the goroutines simulating and publishing the room.
+func (r *room) close() {
+	r.closeOnce.Do(func() {
+		close(r.done)
+	})
+}
+
+// saveClientUpdate validates the keystrokes of the player p sent over its connection,
+// and stores them in the appropriate game world. They are simulated on the room's next physics update.
+// Players that send too many invalid inputs are kicked.
+func (gs *GameServer) saveClientUpdate(r *room, p *player.Player, update util.ClientUpdate) error {
+	r.mutex.Lock()
+	if _, exists := r.game.Players[p.ID]; !exists {
+		r.mutex.Unlock()
+		return fmt.Errorf("player id not found: %v", p.ID)
+	}
+	err := validateInput(r, p, update, gs.clock.Now())
+	kick := err != nil && gs.reject(r, p, err)
+	r.mutex.Unlock()
+
+	if kick {
+		gs.kick(r, p)
+	}
 	if err != nil {
-		return fmt.Errorf("error parsing player id: %s", update.PlayerId)
+		return err
 	}
 
-	if p, exists := game.Players[id]; exists {
-		p.ClientUpdates = append(p.ClientUpdates, update)
-	} else {
-		return fmt.Errorf("player id not found: %v", id)
+	if !p.ClientUpdates.Push(update) {
+		return fmt.Errorf("dropped input %v of player %v: queue is full", update.Seq, p.ID)
 	}
 
 	return nil
 }
 
-// updatePhysics updates each game world at fixed deltas
-func (gs *GameServer) updatePhysics() {
+// removePlayer removes the player from the game, along with everything the room keeps about it.
+// The caller must hold r.mutex.
+func (r *room) removePlayer(id uuid.UUID) {
+	delete(r.game.Players, id)
+	delete(r.guards, id)
+	delete(r.chatLimits, id)
 }
 
-// TODO: rename this maybe
-func (gs *GameServer) processInput(game *game.Game) error {
-	for _, p := range game.Players {
-		bullet := p.Update()
-		if bullet != nil {
-			game.Bullets[bullet.ID] = bullet
+// subscribers returns every player that is connected. The caller must hold r.mutex.
+func (r *room) subscribers() []subscriber {
+	subs := make([]subscriber, 0, len(r.game.Players))
+	for _, p := range r.game.Players {
+		if sub, exists := r.conns[p.Conn]; exists {
+			subs = append(subs, *sub)
 		}
 	}
+	return subs
+}
 
-	return nil
+// updatePhysics steps the room's game world every util.ServerPhysicsPeriod until the room is closed.
+// The delta of each step is measured with the server's clock, so a late tick doesn't slow the game down.
+// The world is only simulated while the game is in progress; otherwise inputs are skipped.
+// Phase changes that happened since the last tick are broadcast to every player.
+func (gs *GameServer) updatePhysics(r *room) {
+	ticker := time.NewTicker(util.ServerPhysicsPeriod)
+	defer ticker.Stop()
+
+	r.mutex.Lock()
+	r.game.PhysicsLastUpdateTime = gs.clock.Now()
+	r.mutex.Unlock()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+			now := gs.clock.Now()
+			r.mutex.Lock()
+			dt := now.Sub(r.game.PhysicsLastUpdateTime)
+			r.game.PhysicsDelta = int(dt.Milliseconds())
+			r.game.PhysicsLastUpdateTime = now
+
+			for _, ig := range r.guards {
+				ig.refill(dt)
+			}
+
+			r.game.UpdatePhase(now)
+			if r.game.Phase == game.PhaseInProgress {
+				r.game.Step(dt)
+			} else {
+				for _, p := range r.game.Players {
+					p.SkipInputs()
+				}
+			}
+
+			events := r.game.DrainEvents()
+			for _, event := range events {
+				r.toSpectators(state.Msg{Type: util.MsgTypeEvent, Payload: event}, now)
+			}
+			subs := r.subscribers()
+			r.mutex.Unlock()
+
+			for _, event := range events {
+				gs.broadcast(subs, state.Msg{Type: util.MsgTypeEvent, Payload: event})
+			}
+		}
+	}
 }
 
-func (gs *GameServer) sendServerUpdate(game *game.Game) error {
-	for _, p := range game.Players {
-		err := p.Conn.Write(context.TODO(), websocket.MessageText)
-		if err != nil {
-			return err
+// saveSnapshotAck records the last snapshot the connection has applied.
+func (gs *GameServer) saveSnapshotAck(r *room, conn *websocket.Conn, ack util.SnapshotAck) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if sub, exists := r.conns[conn]; exists && ack.Seq > sub.ack && ack.Seq <= r.snapshotSeq {
+		sub.ack = ack.Seq
+	}
+}
+
+// sendServerUpdate takes a snapshot of the room's game and writes it to every connected player.
+// Each player gets a delta against the last snapshot it acknowledged,
+// or a full snapshot if it hasn't acknowledged any that is still recent enough.
+// Spectators get the full snapshots taken at least the spectator delay ago.
+// The room is only locked while taking the snapshot, so slow connections don't stall the physics.
+func (gs *GameServer) sendServerUpdate(r *room) error {
+	now := gs.clock.Now()
+
+	r.mutex.Lock()
+	r.snapshotSeq++
+	update := r.game.Snapshot(now)
+	update.Seq = r.snapshotSeq
+	r.snapshots.Add(update)
+
+	r.toSpectators(state.Msg{Type: util.MsgTypeSnapshot, Payload: update}, now)
+	delayed, spectators := r.dueForSpectators(now, gs.spectatorDelay)
+
+	subs := r.subscribers()
+	baselines := make(map[int]state.ServerUpdate)
+	for _, sub := range subs {
+		if baseline, exists := r.snapshots.Get(sub.ack); exists {
+			baselines[sub.ack] = baseline
+		}
+	}
+	r.mutex.Unlock()
+
+	for _, msg := range delayed {
+		gs.broadcast(spectators, msg)
+	}
+
+	// subscribers acknowledging the same snapshot get the same delta
+	groups := make(map[int][]subscriber)
+	for _, sub := range subs {
+		if _, exists := baselines[sub.ack]; exists {
+			groups[sub.ack] = append(groups[sub.ack], sub)
+		} else {
+			groups[0] = append(groups[0], sub)
+		}
+	}
+
+	for seq, group := range groups {
+		if seq == 0 {
+			gs.broadcast(group, state.Msg{Type: util.MsgTypeSnapshot, Payload: update})
+		} else {
+			gs.broadcast(group, state.Msg{Type: util.MsgTypeSnapshot, Payload: state.Diff(baselines[seq], update)})
 		}
 	}
+
 	return nil
 }
+
+// broadcast queues the message to be written to every subscriber, see writePump.
+// It is encoded once per protocol, rather than once per subscriber.
+func (gs *GameServer) broadcast(subs []subscriber, msg state.Msg) {
+	encoded := make(map[string][]byte, len(protocol.Subprotocols))
+
+	for _, sub := range subs {
+		data, exists := encoded[sub.codec.Subprotocol()]
+		if !exists {
+			var err error
+			data, err = sub.codec.Encode(msg)
+			if err != nil {
+				gs.logger.Error("error encoding message", "type", msg.Type, "err", err)
+				return
+			}
+			encoded[sub.codec.Subprotocol()] = data
+		}
+
+		if !sub.out.push(outgoing{msgType: sub.codec.MessageType(), data: data, snapshot: msg.Type == util.MsgTypeSnapshot}) {
+			gs.logger.Debug("outbox overflowed", "type", msg.Type)
+		}
+	}
+
+	if event, ok := msg.Payload.(state.GameEvent); ok {
+		gs.logger.Info("game event", "game", event.GameId, "event", event.Event, "phase", event.Phase)
+	}
+}
+
+// send queues the message to be written to a single subscriber.
+func (gs *GameServer) send(sub subscriber, msg state.Msg) {
+	gs.broadcast([]subscriber{sub}, msg)
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/livingpool/top-down-shooter/game/pkg/player"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
+	"github.com/livingpool/top-down-shooter/game/util"
+)
+
+// ping pings the subscriber every util.PingPeriod until ctx is done.
+// Its pongs are measured by savePong.
+func (gs *GameServer) ping(ctx context.Context, sub subscriber) {
+	ticker := time.NewTicker(util.PingPeriod)
+	defer ticker.Stop()
+
+	seq := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := gs.clock.Now()
+			seq++
+			gs.send(sub, state.Msg{Type: util.MsgTypePing, Payload: state.Ping{Seq: seq, SentAt: int(now.UnixMilli())}})
+		}
+	}
+}
+
+// savePong updates the player's round-trip time, jitter and clock offset with a pong received at receivedAt.
+func (gs *GameServer) savePong(room *room, player *player.Player, pong state.Pong, receivedAt time.Time) {
+	room.mutex.Lock()
+	defer room.mutex.Unlock()
+
+	player.Latency.Sample(time.UnixMilli(int64(pong.SentAt)), time.UnixMilli(int64(pong.RepliedAt)), receivedAt)
+	gs.logger.Debug("measured latency", "player", player.ID, "rtt", player.Latency.RTT, "jitter", player.Latency.Jitter, "offset", player.Latency.ClockOffset)
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/livingpool/top-down-shooter/game/game"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
+	"github.com/livingpool/top-down-shooter/game/util"
+)
+
+// listGames lists every hosted game with its player count.
+func (gs *GameServer) listGames(w http.ResponseWriter, r *http.Request) {
+	gs.mutex.Lock()
+	rooms := make([]*room, 0, len(gs.games))
+	for _, room := range gs.games {
+		rooms = append(rooms, room)
+	}
+	gs.mutex.Unlock()
+
+	games := make([]util.GameInfo, 0, len(rooms))
+	for _, room := range rooms {
+		games = append(games, room.info(false))
+	}
+	slices.SortFunc(games, func(a, b util.GameInfo) int {
+		return strings.Compare(a.GameId, b.GameId)
+	})
+
+	writeJSON(w, http.StatusOK, games)
+}
+
+// getGame shows a game's details, including its players.
+func (gs *GameServer) getGame(w http.ResponseWriter, r *http.Request) {
+	room, err := gs.getRoom(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, room.info(true))
+}
+
+// startGame starts the countdown of a game that is waiting for players.
+func (gs *GameServer) startGame(w http.ResponseWriter, r *http.Request) {
+	room, err := gs.getRoom(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	room.mutex.Lock()
+	if room.game.Phase != game.PhaseWaiting || len(room.game.Players) == 0 {
+		room.mutex.Unlock()
+		http.Error(w, "game is not waiting for players", http.StatusConflict)
+		return
+	}
+	room.game.SetPhase(game.PhaseCountdown, gs.clock.Now())
+	room.mutex.Unlock()
+
+	writeJSON(w, http.StatusOK, room.info(false))
+
+	gs.logger.Info("game started", "game", room.game.ID)
+}
+
+// deleteGame closes the connection of every player in the game and stops hosting it.
+func (gs *GameServer) deleteGame(w http.ResponseWriter, r *http.Request) {
+	room, err := gs.getRoom(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err := gs.removeGame(room); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// removeGame closes the game, tells its players, closes their connections and stops hosting it.
+func (gs *GameServer) removeGame(room *room) error {
+	room.mutex.Lock()
+	if err := room.game.SetPhase(game.PhaseClosed, gs.clock.Now()); err != nil {
+		room.mutex.Unlock()
+		return err
+	}
+	events := room.game.DrainEvents()
+	subs := room.subscribers()
+	playerIds := make([]string, 0, len(room.game.Players))
+	for id := range room.game.Players {
+		playerIds = append(playerIds, id.String())
+	}
+	room.mutex.Unlock()
+
+	for _, event := range events {
+		gs.broadcast(subs, state.Msg{Type: util.MsgTypeEvent, Payload: event})
+	}
+
+	gameId := room.game.ID.String()
+	for _, playerId := range playerIds {
+		if err := gs.deleteSubscriber(playerId, gameId); err != nil {
+			gs.logger.Error("error deleting subscriber", "game", gameId, "player", playerId, "err", err)
+		}
+	}
+
+	gs.mutex.Lock()
+	delete(gs.games, room.game.ID)
+	gs.mutex.Unlock()
+	room.close()
+
+	gs.logger.Info("game deleted", "game", gameId)
+
+	return nil
+}
+
+// info summarizes the room for the lobby.
+func (r *room) info(withPlayers bool) util.GameInfo {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	info := util.GameInfo{
+		GameId:      r.game.ID.String(),
+		Status:      string(r.game.Phase),
+		PlayerCount: len(r.game.Players),
+		MaxPlayers:  util.MaxPlayersPerGame,
+		Spectators:  len(r.spectators),
+	}
+
+	if withPlayers {
+		info.Players = make([]util.PlayerInfo, 0, len(r.game.Players))
+		for _, p := range r.game.Players {
+			info.Players = append(info.Players, util.PlayerInfo{
+				PlayerId:  p.ID.String(),
+				Name:      p.Name,
+				Connected: p.Conn != nil,
+			})
+		}
+		slices.SortFunc(info.Players, func(a, b util.PlayerInfo) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+	}
+
+	return info
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(resp)
+}
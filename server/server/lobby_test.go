@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/livingpool/top-down-shooter/game/game"
+	"github.com/livingpool/top-down-shooter/game/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLobby(t *testing.T) {
+	url, closeFn := setupTest()
+	defer closeFn()
+
+	resp, err := http.Get(url + "/create?name=tim")
+	require.NoError(t, err)
+	var created util.CreatePlayerResp
+	decodeBody(t, resp, http.StatusCreated, &created)
+
+	resp, err = http.Post(url+"/games/"+created.GameId+"/players?name=steven", "", nil)
+	require.NoError(t, err)
+	decodeBody(t, resp, http.StatusCreated, &util.CreatePlayerResp{})
+
+	// list games
+	resp, err = http.Get(url + "/games")
+	require.NoError(t, err)
+	var games []util.GameInfo
+	decodeBody(t, resp, http.StatusOK, &games)
+	require.Len(t, games, 1)
+	assert.Equal(t, created.GameId, games[0].GameId)
+	assert.Equal(t, string(game.PhaseWaiting), games[0].Status)
+	assert.Equal(t, 2, games[0].PlayerCount)
+
+	// game details
+	resp, err = http.Get(url + "/games/" + created.GameId)
+	require.NoError(t, err)
+	var details util.GameInfo
+	decodeBody(t, resp, http.StatusOK, &details)
+	require.Len(t, details.Players, 2)
+	assert.Equal(t, "steven", details.Players[0].Name)
+	assert.Equal(t, "tim", details.Players[1].Name)
+
+	// start, but only once
+	resp, err = http.Post(url+"/games/"+created.GameId+"/start", "", nil)
+	require.NoError(t, err)
+	decodeBody(t, resp, http.StatusOK, &details)
+	assert.Equal(t, string(game.PhaseCountdown), details.Status)
+
+	resp, err = http.Post(url+"/games/"+created.GameId+"/start", "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	// delete
+	req, err := http.NewRequest(http.MethodDelete, url+"/games/"+created.GameId, nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = http.Get(url + "/games/" + created.GameId)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func decodeBody(t *testing.T, resp *http.Response, expectedStatus int, v any) {
+	t.Helper()
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, expectedStatus, resp.StatusCode, string(data))
+	require.NoError(t, json.Unmarshal(data, v))
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	"github.com/coder/websocket"
+	"github.com/livingpool/top-down-shooter/game/util"
+)
+
+// SlowClientPolicy decides what happens to a subscriber whose outbox is full,
+// i.e. a client that can't read as fast as the server writes.
+type SlowClientPolicy int
+
+const (
+	// DropStaleSnapshots drops the queued snapshots to make room, since the next one supersedes them.
+	// The subscriber is only disconnected if there is no snapshot to drop.
+	DropStaleSnapshots SlowClientPolicy = iota
+	// DisconnectSlowClients disconnects the subscriber right away.
+	DisconnectSlowClients
+)
+
+// outgoing is an encoded message waiting to be written.
+type outgoing struct {
+	msgType  websocket.MessageType
+	data     []byte
+	snapshot bool
+}
+
+// outbox is a subscriber's bounded queue of outgoing messages.
+// Messages are pushed by whoever broadcasts them, and written by the subscriber's writePump,
+// so a slow client never blocks the others.
+type outbox struct {
+	mutex    *sync.Mutex
+	queue    []outgoing
+	size     int
+	policy   SlowClientPolicy
+	ready    chan struct{} // signaled when a message is pushed
+	overflow chan struct{} // closed when the subscriber can't keep up and must be disconnected
+}
+
+func newOutbox(size int, policy SlowClientPolicy) *outbox {
+	return &outbox{
+		mutex:    &sync.Mutex{},
+		queue:    make([]outgoing, 0, size),
+		size:     size,
+		policy:   policy,
+		ready:    make(chan struct{}, 1),
+		overflow: make(chan struct{}),
+	}
+}
+
+// push queues a message. It returns false if the outbox overflowed.
+func (o *outbox) push(msg outgoing) bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	select {
+	case <-o.overflow:
+		return false
+	default:
+	}
+
+	if len(o.queue) == o.size && o.policy == DropStaleSnapshots {
+		kept := o.queue[:0]
+		for _, queued := range o.queue {
+			if !queued.snapshot {
+				kept = append(kept, queued)
+			}
+		}
+		o.queue = kept
+	}
+	if len(o.queue) == o.size {
+		close(o.overflow)
+		return false
+	}
+
+	o.queue = append(o.queue, msg)
+	select {
+	case o.ready <- struct{}{}:
+	default:
+	}
+
+	return true
+}
+
+// pop takes every queued message.
+func (o *outbox) pop() []outgoing {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	msgs := make([]outgoing, len(o.queue))
+	copy(msgs, o.queue)
+	o.queue = o.queue[:0]
+
+	return msgs
+}
+
+// writePump writes the subscriber's queued messages until ctx is done.
+// Each write must finish within util.WriteTimeout. If a write fails or the outbox overflows,
+// the connection is closed, which ends the subscriber's reader too.
+func (gs *GameServer) writePump(ctx context.Context, sub subscriber) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sub.out.overflow:
+			gs.logger.Warn("disconnecting slow client", "err", "outbox is full")
+			sub.conn.Close(websocket.StatusPolicyViolation, "client is too slow")
+			return
+		case <-sub.out.ready:
+			for _, msg := range sub.out.pop() {
+				writeCtx, cancel := context.WithTimeout(ctx, util.WriteTimeout)
+				err := sub.conn.Write(writeCtx, msg.msgType, msg.data)
+				cancel()
+				if err != nil {
+					gs.logger.Debug("error writing message", "err", err)
+					sub.conn.CloseNow()
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutbox(t *testing.T) {
+	snapshot := outgoing{data: []byte("snapshot"), snapshot: true}
+	event := outgoing{data: []byte("event")}
+
+	t.Run("drop stale snapshots", func(t *testing.T) {
+		out := newOutbox(3, DropStaleSnapshots)
+		assert.True(t, out.push(snapshot))
+		assert.True(t, out.push(event))
+		assert.True(t, out.push(snapshot))
+
+		// the queued snapshots make room for the new one
+		assert.True(t, out.push(snapshot))
+		assert.Equal(t, []outgoing{event, snapshot}, out.pop())
+
+		// nothing left to drop
+		for range 3 {
+			assert.True(t, out.push(event))
+		}
+		assert.False(t, out.push(snapshot))
+		assert.False(t, out.push(event), "an overflowed outbox stays overflowed")
+	})
+
+	t.Run("disconnect slow clients", func(t *testing.T) {
+		out := newOutbox(2, DisconnectSlowClients)
+		assert.True(t, out.push(snapshot))
+		assert.True(t, out.push(snapshot))
+		assert.False(t, out.push(snapshot))
+
+		select {
+		case <-out.overflow:
+		default:
+			t.Fatal("overflow is not signaled")
+		}
+	})
+}
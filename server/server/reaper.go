@@ -0,0 +1,81 @@
+package server
+
+import (
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/livingpool/top-down-shooter/game/pkg/player"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
+	"github.com/livingpool/top-down-shooter/game/util"
+)
+
+// disconnect marks the player as disconnected once its connection is gone.
+// The player stays in the game, so it can reconnect with the same player_id within the grace period.
+// Nothing happens if the player has already reconnected with another connection.
+func (gs *GameServer) disconnect(room *room, player *player.Player, conn *websocket.Conn) {
+	conn.CloseNow()
+
+	room.mutex.Lock()
+	delete(room.conns, conn)
+	if player.Conn != conn {
+		room.mutex.Unlock()
+		return
+	}
+	player.Conn = nil
+	player.DisconnectedAt = gs.clock.Now()
+	subs := room.subscribers()
+	room.mutex.Unlock()
+
+	gs.logger.Info("player disconnected", "game", room.game.ID, "player", player.ID)
+	gs.broadcast(subs, state.Msg{Type: util.MsgTypeLeave, Payload: state.Presence{PlayerId: player.ID.String(), Name: player.Name}})
+}
+
+// reap periodically drops the players that didn't reconnect within the grace period,
+// and deletes the games that had no connected players for longer than the idle timeout.
+func (gs *GameServer) reap() {
+	ticker := time.NewTicker(util.ReapPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-gs.done:
+			return
+		case <-ticker.C:
+			now := gs.clock.Now()
+			gs.mutex.Lock()
+			rooms := make([]*room, 0, len(gs.games))
+			for _, room := range gs.games {
+				rooms = append(rooms, room)
+			}
+			gs.mutex.Unlock()
+
+			for _, room := range rooms {
+				if gs.reapPlayers(room, now) {
+					if err := gs.removeGame(room); err != nil {
+						gs.logger.Error("error deleting idle game", "game", room.game.ID, "err", err)
+					}
+				}
+			}
+		}
+	}
+}
+
+// reapPlayers drops the room's players whose grace period has run out,
+// and reports whether the room has been idle for too long.
+func (gs *GameServer) reapPlayers(room *room, now time.Time) bool {
+	room.mutex.Lock()
+	defer room.mutex.Unlock()
+
+	for id, p := range room.game.Players {
+		if p.Conn != nil {
+			room.lastActiveAt = now
+			continue
+		}
+		if now.Sub(p.DisconnectedAt) > gs.gracePeriod {
+			room.removePlayer(id)
+			gs.logger.Info("player dropped", "game", room.game.ID, "player", id)
+		}
+	}
+
+	return now.Sub(room.lastActiveAt) > gs.idleTimeout
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/livingpool/top-down-shooter/game/game"
+	"github.com/livingpool/top-down-shooter/game/pkg/clock"
+	"github.com/livingpool/top-down-shooter/game/pkg/player"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReapPlayers(t *testing.T) {
+	clk := clock.NewManual(time.Unix(0, 0))
+	gs := NewGameServer(WithClock(clk), WithReconnectGracePeriod(10*time.Second), WithIdleTimeout(time.Minute))
+	defer gs.Close()
+
+	g := game.NewGame(true)
+	g.Clock = clk
+	room := newRoom(g)
+	require.NoError(t, gs.addGame(room))
+
+	// both are yet to connect, so their grace period starts when they are added
+	tim, steven := player.NewPlayer("tim"), player.NewPlayer("steven")
+	require.NoError(t, gs.addPlayer(steven, room))
+	clk.Advance(10 * time.Second)
+	require.NoError(t, gs.addPlayer(tim, room))
+	clk.Advance(5 * time.Second)
+
+	// steven didn't make it back in time
+	assert.False(t, gs.reapPlayers(room, clk.Now()))
+	assert.Contains(t, room.game.Players, tim.ID)
+	assert.NotContains(t, room.game.Players, steven.ID)
+
+	// nobody has been connected for too long
+	clk.Advance(time.Minute)
+	assert.True(t, gs.reapPlayers(room, clk.Now()))
+}
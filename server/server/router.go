@@ -0,0 +1,49 @@
+package server
+
+import (
+	"github.com/livingpool/top-down-shooter/game/pkg/player"
+	"github.com/livingpool/top-down-shooter/game/pkg/protocol"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
+	"github.com/livingpool/top-down-shooter/game/util"
+)
+
+// newRouter routes the messages a player sends over its connection.
+// Messages the server only ever sends, like snapshots, are unknown to it.
+func (gs *GameServer) newRouter(room *room, player *player.Player, sub subscriber) *protocol.Router {
+	router := protocol.NewRouter()
+
+	protocol.Handle(router, util.MsgTypeInput, func(input util.ClientUpdate) error {
+		gs.logger.Debug("accepted client update", "msg", input)
+		return gs.saveClientUpdate(room, player, input)
+	})
+
+	protocol.Handle(router, util.MsgTypeAck, func(ack util.SnapshotAck) error {
+		gs.saveSnapshotAck(room, sub.conn, ack)
+		return nil
+	})
+
+	protocol.Handle(router, util.MsgTypePing, func(ping state.Ping) error {
+		gs.send(sub, state.Msg{Type: util.MsgTypePong, Payload: state.Pong{
+			Seq:       ping.Seq,
+			SentAt:    ping.SentAt,
+			RepliedAt: int(gs.clock.Now().UnixMilli()),
+		}})
+		return nil
+	})
+
+	protocol.Handle(router, util.MsgTypePong, func(pong state.Pong) error {
+		gs.savePong(room, player, pong, gs.clock.Now())
+		return nil
+	})
+
+	protocol.Handle(router, util.MsgTypeChat, func(chat state.Chat) error {
+		return gs.sendChat(room, player, chat, gs.clock.Now())
+	})
+
+	protocol.Handle(router, util.MsgTypeError, func(protocolErr state.ProtocolError) error {
+		gs.logger.Warn("client rejected a message", "player", player.ID, "err", protocolErr.Message)
+		return nil
+	})
+
+	return router
+}
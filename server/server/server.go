@@ -8,31 +8,111 @@ import (
 	"log/slog"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/coder/websocket"
 	"github.com/google/uuid"
 	"github.com/livingpool/top-down-shooter/game/game"
+	"github.com/livingpool/top-down-shooter/game/pkg/clock"
 	"github.com/livingpool/top-down-shooter/game/pkg/player"
+	"github.com/livingpool/top-down-shooter/game/pkg/protocol"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
 	"github.com/livingpool/top-down-shooter/game/util"
 )
 
 // GameServer maintains the set of active players
 // and broadcasts game states at fixed intervals.
 type GameServer struct {
-	games    map[uuid.UUID]*game.Game // active games, each having one or more players
-	serveMux *http.ServeMux           // serveMux routes endpoints to appropriate handlers
+	games    map[uuid.UUID]*room // active games, each having one or more players
+	serveMux *http.ServeMux      // serveMux routes endpoints to appropriate handlers
 	mutex    *sync.Mutex
 	logger   *slog.Logger
+
+	rewindWindow   time.Duration // how far in the past lag compensation may check bullet hits
+	gracePeriod    time.Duration // how long a disconnected player can take to reconnect
+	idleTimeout    time.Duration // how long a game can go without connected players
+	slowClients    SlowClientPolicy
+	sessions       sessions      // issues the tokens players join with
+	spectatorDelay time.Duration // how far behind the players the spectators watch
+	clock          clock.Clock   // tells the time to the server and its games
+	done           chan struct{} // closed by Close to stop the reaper
+}
+
+// Option configures a GameServer.
+type Option func(*GameServer)
+
+// WithRewindWindow bounds how far in the past bullet hits are checked to compensate for the shooter's latency.
+// Zero disables lag compensation.
+func WithRewindWindow(d time.Duration) Option {
+	return func(gs *GameServer) {
+		gs.rewindWindow = d
+	}
+}
+
+// WithReconnectGracePeriod sets how long a disconnected player is kept in its game, waiting for it to reconnect.
+func WithReconnectGracePeriod(d time.Duration) Option {
+	return func(gs *GameServer) {
+		gs.gracePeriod = d
+	}
+}
+
+// WithIdleTimeout sets how long a game is kept once none of its players are connected.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(gs *GameServer) {
+		gs.idleTimeout = d
+	}
+}
+
+// WithSlowClientPolicy sets what happens to clients that can't keep up with the messages sent to them.
+func WithSlowClientPolicy(policy SlowClientPolicy) Option {
+	return func(gs *GameServer) {
+		gs.slowClients = policy
+	}
+}
+
+// WithSessionSecret sets the secret session tokens are signed with.
+// Without it, a random secret is used and tokens are no longer valid once the server restarts.
+func WithSessionSecret(secret []byte) Option {
+	return func(gs *GameServer) {
+		gs.sessions.secret = secret
+	}
+}
+
+// WithSpectatorDelay delays what the spectators see, so they can't tell the players what the others are up to.
+func WithSpectatorDelay(d time.Duration) Option {
+	return func(gs *GameServer) {
+		gs.spectatorDelay = d
+	}
 }
 
-func NewGameServer() *GameServer {
+// WithClock sets the clock of the server and the games it hosts, e.g. a clock.Manual in tests.
+// Tickers still fire in real time, but every tick reads the time from the clock.
+func WithClock(c clock.Clock) Option {
+	return func(gs *GameServer) {
+		gs.clock = c
+	}
+}
+
+// NewGameServer creates a GameServer and starts reaping its idle games and players.
+// Call Close to stop it.
+func NewGameServer(opts ...Option) *GameServer {
 	serveMux := http.NewServeMux()
 
 	gs := &GameServer{
-		games:    make(map[uuid.UUID]*game.Game),
-		serveMux: serveMux,
-		mutex:    &sync.Mutex{},
-		logger:   slog.Default(),
+		games:        make(map[uuid.UUID]*room),
+		serveMux:     serveMux,
+		mutex:        &sync.Mutex{},
+		logger:       slog.Default(),
+		rewindWindow: util.DefaultRewindWindow,
+		gracePeriod:  util.DefaultReconnectGracePeriod,
+		idleTimeout:  util.DefaultIdleGameTimeout,
+		slowClients:  DropStaleSnapshots,
+		sessions:     sessions{secret: newSecret(), ttl: util.SessionTokenTTL},
+		clock:        clock.Real{},
+		done:         make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(gs)
 	}
 
 	serveMux.HandleFunc("/", serveHome)
@@ -43,18 +123,48 @@ func NewGameServer() *GameServer {
 	serveMux.HandleFunc("/join", func(w http.ResponseWriter, r *http.Request) {
 		gs.join(w, r)
 	})
-	// serveMux.HandleFunc("/delete")
-	// serveMux.HandleFunc("/start")
+	serveMux.HandleFunc("/spectate", func(w http.ResponseWriter, r *http.Request) {
+		gs.spectate(w, r)
+	})
+	serveMux.HandleFunc("POST /games/{id}/players", func(w http.ResponseWriter, r *http.Request) {
+		gs.addPlayerToGame(w, r)
+	})
+	serveMux.HandleFunc("GET /games", func(w http.ResponseWriter, r *http.Request) {
+		gs.listGames(w, r)
+	})
+	serveMux.HandleFunc("GET /games/{id}", func(w http.ResponseWriter, r *http.Request) {
+		gs.getGame(w, r)
+	})
+	serveMux.HandleFunc("POST /games/{id}/start", func(w http.ResponseWriter, r *http.Request) {
+		gs.startGame(w, r)
+	})
+	serveMux.HandleFunc("DELETE /games/{id}", func(w http.ResponseWriter, r *http.Request) {
+		gs.deleteGame(w, r)
+	})
+
+	go gs.reap()
 
 	return gs
 }
 
+// Close stops the reaper and every hosted game. Connections are left to the http server to close.
+func (gs *GameServer) Close() {
+	close(gs.done)
+
+	gs.mutex.Lock()
+	defer gs.mutex.Unlock()
+
+	for _, room := range gs.games {
+		room.close()
+	}
+}
+
 func (gs *GameServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	gs.serveMux.ServeHTTP(w, r)
 }
 
-// create creates a new game and a new player and returns the associated ids.
-// The client should call /join after /create.
+// create creates a new game and a new player and returns the associated ids and session token.
+// The client should call /join with the token after /create.
 func (gs *GameServer) create(w http.ResponseWriter, r *http.Request) {
 	playerName := r.URL.Query().Get("name")
 	if playerName == "" {
@@ -65,14 +175,68 @@ func (gs *GameServer) create(w http.ResponseWriter, r *http.Request) {
 
 	player := player.NewPlayer(playerName)
 	game := game.NewGame(true)
-	if err := gs.addPlayer(player, game); err != nil {
+	game.RewindWindow = gs.rewindWindow
+	game.Clock = gs.clock
+	room := newRoom(game)
+	if err := gs.addGame(room); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if err := gs.addPlayer(player, room); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	go gs.updatePhysics(room)
+	go gs.publish(room)
 
 	resp, err := json.Marshal(util.CreatePlayerResp{
 		PlayerId: player.ID.String(),
 		GameId:   game.ID.String(),
+		Token:    gs.sessions.issue(game.ID, player.ID, gs.clock.Now()),
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(resp)
+
+	gs.mutex.Lock()
+	games := len(gs.games)
+	gs.mutex.Unlock()
+
+	gs.logger.Info("new game created", "# of games", games, "player name", playerName)
+}
+
+// addPlayerToGame adds a new player to an existing game and returns the associated ids and session token.
+// The client should call /join afterwards, just like after /create.
+func (gs *GameServer) addPlayerToGame(w http.ResponseWriter, r *http.Request) {
+	playerName := r.URL.Query().Get("name")
+	if playerName == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("no query param: name"))
+		return
+	}
+
+	room, err := gs.getRoom(r.PathValue("id"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	player := player.NewPlayer(playerName)
+	if err := gs.addPlayer(player, room); err != nil {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	resp, err := json.Marshal(util.CreatePlayerResp{
+		PlayerId: player.ID.String(),
+		GameId:   room.game.ID.String(),
+		Token:    gs.sessions.issue(room.game.ID, player.ID, gs.clock.Now()),
 	})
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -82,65 +246,117 @@ func (gs *GameServer) create(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 	w.Write(resp)
 
-	gs.logger.Info("new game created", "# of games", len(gs.games), "player name", playerName)
+	gs.logger.Info("player added to game", "game", room.game.ID, "player name", playerName)
 }
 
-// join adds the requested player to the game.
-// join upgrades the connection to a websocket.
+// join upgrades the connection of a player created by /create or /games/{id}/players to a websocket.
+// The player is identified by the session token it was given, and can only be connected once at a time.
 func (gs *GameServer) join(w http.ResponseWriter, r *http.Request) {
-	playerId := r.URL.Query().Get("player_id")
-	gameId := r.URL.Query().Get("game_id")
+	gameId, playerId, err := gs.sessions.verify(r.URL.Query().Get("token"), gs.clock.Now())
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(err.Error()))
+		return
+	}
 
-	game, player, err := gs.getPlayer(playerId, gameId)
+	room, player, err := gs.getPlayer(playerId.String(), gameId.String())
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("player id or game id is not uuid"))
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(err.Error()))
+		return
 	}
 
-	gs.subscribe(w, r, game, player)
+	room.mutex.Lock()
+	connected := player.Conn != nil
+	room.mutex.Unlock()
+	if connected {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte("player is already connected"))
+		return
+	}
+
+	gs.subscribe(w, r, room, player)
 }
 
 // subscribe accepts the websocket connetion and subcribes it to future game updates.
-// It also listens for client updates and save them to a buffered channel.
-func (gs *GameServer) subscribe(w http.ResponseWriter, r *http.Request, game *game.Game, player *player.Player) {
-	conn, err := websocket.Accept(w, r, nil)
+// It also listens for messages from the client, and routes them to their handler, see newRouter.
+// Messages are encoded with the protocol the client offered as a subprotocol, JSON by default.
+func (gs *GameServer) subscribe(w http.ResponseWriter, r *http.Request, room *room, player *player.Player) {
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		Subprotocols: protocol.Subprotocols,
+	})
 	if err != nil {
 		gs.logger.Error("error upgrading conn to a websocket: %v", "err", err)
 		return
 	}
-	player.Conn = conn
+	codec := protocol.ForSubprotocol(conn.Subprotocol())
 
-	_, reader, err := conn.Reader(context.Background())
-	if err != nil {
-		gs.logger.Error("error creating websocket reader", "err", err)
+	sub := subscriber{conn: conn, codec: codec, out: newOutbox(util.ClientUpdateBufferSize, gs.slowClients)}
+
+	room.mutex.Lock()
+	// another connection may have joined as the player since join checked
+	if player.Conn != nil {
+		room.mutex.Unlock()
+		conn.Close(websocket.StatusPolicyViolation, "player is already connected")
 		return
 	}
+	player.Conn = conn
+	room.conns[conn] = &sub
+	room.resetInputs(player)
+	subs := room.subscribers()
+	room.mutex.Unlock()
+	defer gs.disconnect(room, player, conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go gs.writePump(ctx, sub)
+	go gs.ping(ctx, sub)
+
+	gs.broadcast(subs, state.Msg{Type: util.MsgTypeJoin, Payload: state.Presence{PlayerId: player.ID.String(), Name: player.Name}})
+
+	router := gs.newRouter(room, player, sub)
 
 	for {
-		data, err := io.ReadAll(reader)
+		_, reader, err := conn.Reader(ctx)
 		if err != nil {
 			gs.logger.Debug("connection closed", "err", err)
 			return
 		}
 
-		var msg util.ClientUpdate
-		if err := json.Unmarshal(data, &msg); err != nil {
-			gs.logger.Error("error unmarshaling client data", "err", err)
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			gs.logger.Debug("connection closed", "err", err)
 			return
 		}
 
-		slog.Debug("accepted client update", "msg", msg)
-		player.ClientUpdates = append(player.ClientUpdates, msg)
-
-		// poc
-		if err := gs.processInput(game); err != nil {
-			gs.logger.Error("error processing input", "err", err)
+		// a bad message is rejected, but the connection is kept open
+		msg, err := codec.Decode(data)
+		if err == nil {
+			err = router.Route(msg)
+		}
+		if err != nil {
+			gs.logger.Warn("rejected client message", "player", player.ID, "type", msg.Type, "err", err)
+			gs.send(sub, state.Msg{Type: util.MsgTypeError, Payload: state.ProtocolError{Message: err.Error()}})
 		}
 	}
 }
 
-// publish publishes each room's game state at fixed intervals to every subscriber in the room.
-func (gs *GameServer) publish() {
+// publish publishes the room's game state every util.ServerUpdatePeriod to every subscriber in the room,
+// until the room is closed.
+func (gs *GameServer) publish(room *room) {
+	ticker := time.NewTicker(util.ServerUpdatePeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-room.done:
+			return
+		case <-ticker.C:
+			if err := gs.sendServerUpdate(room); err != nil {
+				gs.logger.Error("error publishing server update", "err", err)
+			}
+		}
+	}
 }
 
 func serveHome(w http.ResponseWriter, r *http.Request) {
@@ -160,46 +376,78 @@ func serveHealth(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Game server is healthy!"))
 }
 
-func (gs *GameServer) getPlayer(playerIdStr, gameIdStr string) (*game.Game, *player.Player, error) {
-	playerId, err := uuid.Parse(playerIdStr)
-	if err != nil {
-		return nil, nil, fmt.Errorf("playerId is not uuid")
-	}
+func (gs *GameServer) getRoom(gameIdStr string) (*room, error) {
 	gameId, err := uuid.Parse(gameIdStr)
 	if err != nil {
-		return nil, nil, fmt.Errorf("gameId is not uuid")
+		return nil, fmt.Errorf("gameId is not uuid")
 	}
 
 	gs.mutex.Lock()
 	defer gs.mutex.Unlock()
 
-	game, exists := gs.games[gameId]
+	room, exists := gs.games[gameId]
 	if !exists {
-		return nil, nil, fmt.Errorf("game %v does not exist", gameId)
+		return nil, fmt.Errorf("game %v does not exist", gameId)
+	}
+
+	return room, nil
+}
+
+func (gs *GameServer) getPlayer(playerIdStr, gameIdStr string) (*room, *player.Player, error) {
+	playerId, err := uuid.Parse(playerIdStr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("playerId is not uuid")
+	}
+
+	room, err := gs.getRoom(gameIdStr)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	player, exists := game.Players[playerId]
+	room.mutex.Lock()
+	defer room.mutex.Unlock()
+
+	player, exists := room.game.Players[playerId]
 	if !exists {
 		return nil, nil, fmt.Errorf("player %v does not exist", playerId)
 	}
 
-	return game, player, nil
+	return room, player, nil
 }
 
-func (gs *GameServer) addPlayer(player *player.Player, game *game.Game) error {
+func (gs *GameServer) addGame(room *room) error {
 	gs.mutex.Lock()
 	defer gs.mutex.Unlock()
 
-	if _, exists := gs.games[game.ID]; exists {
-		return fmt.Errorf("game %v exists", game.ID)
+	if _, exists := gs.games[room.game.ID]; exists {
+		return fmt.Errorf("game %v exists", room.game.ID)
 	} else {
-		gs.games[game.ID] = game
+		gs.games[room.game.ID] = room
+	}
+
+	return nil
+}
+
+// addPlayer adds the player to the room's game and moves it to a free spawn position.
+// Players can only be added while the game is waiting for players.
+func (gs *GameServer) addPlayer(player *player.Player, room *room) error {
+	room.mutex.Lock()
+	defer room.mutex.Unlock()
+
+	if room.game.Phase != game.PhaseWaiting {
+		return fmt.Errorf("game %v has already started", room.game.ID)
+	}
+	if len(room.game.Players) >= util.MaxPlayersPerGame {
+		return fmt.Errorf("game %v is full", room.game.ID)
 	}
 
-	if _, exists := game.Players[player.ID]; exists {
+	if _, exists := room.game.Players[player.ID]; exists {
 		return fmt.Errorf("player %v exists", player.ID)
 	} else {
-		game.Players[player.ID] = player
+		player.Object.Vector = room.game.FreeSpawnPosition()
+		player.Team = room.game.SmallestTeam()
+		player.DisconnectedAt = gs.clock.Now() // it has yet to connect, so the grace period applies
+		room.game.Players[player.ID] = player
 	}
 
 	return nil
@@ -211,26 +459,31 @@ func (gs *GameServer) deleteSubscriber(playerIdStr, gameIdStr string) error {
 	if err != nil {
 		return fmt.Errorf("playerId is not uuid")
 	}
-	gameId, err := uuid.Parse(gameIdStr)
+
+	room, err := gs.getRoom(gameIdStr)
 	if err != nil {
-		return fmt.Errorf("gameId is not uuid")
+		return err
 	}
 
-	gs.mutex.Lock()
-	defer gs.mutex.Unlock()
-
-	game, exists := gs.games[gameId]
-	if !exists {
-		return fmt.Errorf("game %v doesn't exist", gameId)
+	room.mutex.Lock()
+	player, exists := room.game.Players[playerId]
+	var conn *websocket.Conn
+	if exists {
+		conn = player.Conn
+		room.removePlayer(playerId)
 	}
+	room.mutex.Unlock()
 
-	player, exists := game.Players[playerId]
 	if !exists {
-		return fmt.Errorf("game: %v doesn't contain player: %v", gameId, playerId)
+		return fmt.Errorf("game: %v doesn't contain player: %v", room.game.ID, playerId)
 	}
-	delete(game.Players, playerId)
 
-	err = player.Conn.Close(websocket.StatusNormalClosure, "delete request")
+	// the close handshake needs the subscriber's reader, which may be waiting on the room,
+	// so the connection must be closed without holding any lock
+	if conn == nil {
+		return nil
+	}
+	err = conn.Close(websocket.StatusNormalClosure, "delete request")
 	if err != nil {
 		return fmt.Errorf("error closing connection: %v", err)
 	}
@@ -7,10 +7,17 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/coder/websocket"
+	"github.com/google/uuid"
+	"github.com/livingpool/top-down-shooter/game/pkg/protocol"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
 	"github.com/livingpool/top-down-shooter/game/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TODO: im not entirely sure of the behavior if i set up the game server and then run all tests in parallel
@@ -18,10 +25,115 @@ import (
 func TestGameServer(t *testing.T) {
 }
 
+func TestJoin(t *testing.T) {
+	url, closeFn := setupTest()
+	defer closeFn()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tim := newClient(t, ctx, url, "tim")
+	defer tim.close()
+
+	// the player's connection is only registered once it is told it joined
+	msg, err := tim.nextMessage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, util.MsgTypeJoin, msg.Type)
+
+	// a token signed with another secret, for the same player
+	gameId, playerId := uuid.MustParse(tim.gameId), uuid.MustParse(tim.playerId)
+	forged := sessions{secret: []byte("forged"), ttl: time.Hour}.issue(gameId, playerId, time.Now())
+	_, resp, err := websocket.Dial(ctx, joinURL(url, forged), nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// the player is already connected
+	_, resp, err = websocket.Dial(ctx, joinURL(url, tim.token), nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestSnapshotDeltas(t *testing.T) {
+	url, closeFn := setupTest()
+	defer closeFn()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tim := newClient(t, ctx, url, "tim")
+	defer tim.close()
+	steven := newClientInGame(t, ctx, url, tim.gameId, "steven")
+	defer steven.close()
+
+	// tim acknowledges a snapshot, steven doesn't
+	snapshot := tim.nextSnapshot(ctx)
+	require.NoError(t, tim.send(ctx, state.Msg{Type: util.MsgTypeAck, Payload: util.SnapshotAck{Seq: snapshot.Seq}}))
+
+	delta := tim.nextSnapshot(ctx)
+	for delta.Baseline == 0 {
+		delta = tim.nextSnapshot(ctx)
+	}
+	assert.Equal(t, snapshot.Seq, delta.Baseline, "tim gets deltas against the snapshot it acknowledged")
+
+	for range 3 {
+		assert.Zero(t, steven.nextSnapshot(ctx).Baseline, "steven gets full snapshots")
+	}
+
+	// once steven acknowledges the same snapshot, both get the same deltas
+	require.NoError(t, steven.send(ctx, state.Msg{Type: util.MsgTypeAck, Payload: util.SnapshotAck{Seq: snapshot.Seq}}))
+	delta = steven.nextSnapshot(ctx)
+	for delta.Baseline == 0 {
+		delta = steven.nextSnapshot(ctx)
+	}
+	assert.Equal(t, snapshot.Seq, delta.Baseline)
+	for other := tim.nextSnapshot(ctx); other.Seq <= delta.Seq; other = tim.nextSnapshot(ctx) {
+		if other.Seq == delta.Seq {
+			assert.Equal(t, delta, other)
+		}
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	url, closeFn := setupTest()
+	defer closeFn()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tim := newClient(t, ctx, url, "tim")
+	for seq := 1; seq <= 5; seq++ {
+		require.NoError(t, tim.sendInput(ctx, seq))
+	}
+	tim.awaitInput(ctx, 5)
+	require.NoError(t, tim.close())
+
+	// the new client counts its inputs from the start again
+	var conn *websocket.Conn
+	require.Eventually(t, func() bool {
+		var err error
+		conn, _, err = websocket.Dial(ctx, joinURL(url, tim.token), nil)
+		return err == nil
+	}, time.Second, 10*time.Millisecond, "the old connection is let go")
+	tim.conn = conn
+	defer tim.close()
+
+	for seq := 1; seq <= 3; seq++ {
+		require.NoError(t, tim.sendInput(ctx, seq))
+	}
+	tim.awaitInput(ctx, 3)
+}
+
 func setupTest() (url string, closeFn func()) {
 	gs := NewGameServer()
 	server := httptest.NewServer(gs)
-	return server.URL, server.Close
+	return server.URL, func() {
+		server.Close()
+		gs.Close()
+	}
+}
+
+func joinURL(serverURL, token string) string {
+	return serverURL + "/join?token=" + url.QueryEscape(token)
 }
 
 // websocket client for testing
@@ -31,54 +143,115 @@ type client struct {
 	conn     *websocket.Conn
 	gameId   string
 	playerId string
+	token    string
 }
 
+// newClient creates a new game with a player named playerName, and joins it as that player.
 func newClient(t *testing.T, ctx context.Context, url string, playerName string) *client {
 	t.Helper()
 
-	resp, err := http.Get(url + "/create")
+	resp, err := http.Get(url + "/create?name=" + playerName)
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	return dialClient(t, ctx, url, resp)
+}
+
+// newClientInGame adds a player named playerName to an existing game, and joins it as that player.
+func newClientInGame(t *testing.T, ctx context.Context, url string, gameId string, playerName string) *client {
+	t.Helper()
+
+	resp, err := http.Post(url+"/games/"+gameId+"/players?name="+playerName, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dialClient(t, ctx, url, resp)
+}
+
+func dialClient(t *testing.T, ctx context.Context, url string, resp *http.Response) *client {
+	t.Helper()
 	defer resp.Body.Close()
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
 		t.Fatal(err)
 	}
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status %v but got %v: %s", http.StatusCreated, resp.StatusCode, data)
+	}
 
 	var createResp util.CreatePlayerResp
 	if err := json.Unmarshal(data, &createResp); err != nil {
 		t.Fatal(err)
 	}
 
-	conn, _, err := websocket.Dial(ctx, url+"/join", nil)
+	conn, _, err := websocket.Dial(ctx, joinURL(url, createResp.Token), nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	return &client{t: t, url: url, conn: conn, gameId: createResp.GameId, playerId: createResp.PlayerId}
+	return &client{t: t, url: url, conn: conn, gameId: createResp.GameId, playerId: createResp.PlayerId, token: createResp.Token}
 }
 
-func (cl *client) publishMsg(ctx context.Context, msg []byte) error {
+func (cl *client) send(ctx context.Context, msg state.Msg) error {
 	cl.t.Helper()
-	return cl.conn.Write(ctx, websocket.MessageText, msg)
+
+	data, err := protocol.JSON{}.Encode(msg)
+	if err != nil {
+		return err
+	}
+	return cl.conn.Write(ctx, websocket.MessageText, data)
+}
+
+func (cl *client) sendInput(ctx context.Context, seq int) error {
+	cl.t.Helper()
+	return cl.send(ctx, state.Msg{Type: util.MsgTypeInput, Payload: util.ClientUpdate{PlayerId: cl.playerId, Seq: seq}})
+}
+
+// awaitInput waits for a snapshot acknowledging the player's input seq.
+func (cl *client) awaitInput(ctx context.Context, seq int) {
+	cl.t.Helper()
+
+	for {
+		for _, p := range cl.nextSnapshot(ctx).Players {
+			if p.ID == cl.playerId && p.LastInputSeq == seq {
+				return
+			}
+		}
+	}
 }
 
-func (cl *client) nextMessage(ctx context.Context) (string, error) {
+func (cl *client) nextMessage(ctx context.Context) (state.Msg, error) {
 	cl.t.Helper()
 
 	typ, data, err := cl.conn.Read(ctx)
 	if err != nil {
-		return "", err
+		return state.Msg{}, err
 	}
 
 	if typ != websocket.MessageText {
 		cl.conn.Close(websocket.StatusUnsupportedData, "expected text message")
-		return "", fmt.Errorf("expected text message but got %v", typ)
+		return state.Msg{}, fmt.Errorf("expected text message but got %v", typ)
 	}
 
-	return string(data), nil
+	return protocol.JSON{}.Decode(data)
+}
+
+// nextSnapshot skips every message up to the next snapshot.
+func (cl *client) nextSnapshot(ctx context.Context) state.ServerUpdate {
+	cl.t.Helper()
+
+	for {
+		msg, err := cl.nextMessage(ctx)
+		if err != nil {
+			cl.t.Fatal(err)
+		}
+		if update, ok := msg.Payload.(state.ServerUpdate); ok {
+			return update
+		}
+	}
 }
 
 func (cl *client) close() error {
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session tokens let a player join its game, without anyone else being able to join as it.
+//
+// A token is the game id, the player id and an expiry, followed by an HMAC-SHA256 of them
+// signed with the server's secret, both base64 encoded and separated by a dot.
+// Tokens are not encrypted: they prove who the player is, but don't hide it.
+
+var (
+	errInvalidToken = errors.New("invalid session token")
+	errExpiredToken = errors.New("session token has expired")
+)
+
+type sessions struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// newSecret returns a random secret, for servers that weren't given one.
+// Tokens signed with it are only valid until the server restarts.
+func newSecret() []byte {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return secret
+}
+
+// issue returns a token for the player of the game, valid for s.ttl from now.
+func (s sessions) issue(gameId, playerId uuid.UUID, now time.Time) string {
+	payload := make([]byte, 0, 40)
+	payload = append(payload, gameId[:]...)
+	payload = append(payload, playerId[:]...)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(now.Add(s.ttl).Unix()))
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(s.sign(payload))
+}
+
+// verify checks the token's signature and expiry, and returns the ids it was issued for.
+func (s sessions) verify(token string, now time.Time) (gameId, playerId uuid.UUID, err error) {
+	encodedPayload, encodedSig, found := strings.Cut(token, ".")
+	if !found {
+		return uuid.Nil, uuid.Nil, errInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil || len(payload) != 40 {
+		return uuid.Nil, uuid.Nil, errInvalidToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSig)
+	if err != nil || !hmac.Equal(sig, s.sign(payload)) {
+		return uuid.Nil, uuid.Nil, errInvalidToken
+	}
+
+	expiresAt := time.Unix(int64(binary.BigEndian.Uint64(payload[32:])), 0)
+	if now.After(expiresAt) {
+		return uuid.Nil, uuid.Nil, errExpiredToken
+	}
+
+	return uuid.UUID(payload[:16]), uuid.UUID(payload[16:32]), nil
+}
+
+func (s sessions) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
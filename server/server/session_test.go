@@ -0,0 +1,44 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessions(t *testing.T) {
+	s := sessions{secret: []byte("secret"), ttl: time.Hour}
+	gameId, playerId := uuid.New(), uuid.New()
+	now := time.Now()
+
+	token := s.issue(gameId, playerId, now)
+
+	g, p, err := s.verify(token, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, gameId, g)
+	assert.Equal(t, playerId, p)
+
+	_, _, err = s.verify(token, now.Add(2*time.Hour))
+	assert.ErrorIs(t, err, errExpiredToken)
+
+	// signed with another secret
+	other := sessions{secret: []byte("other"), ttl: time.Hour}
+	_, _, err = other.verify(token, now)
+	assert.ErrorIs(t, err, errInvalidToken)
+
+	// tampered with, e.g. to join as someone else
+	payload, sig, _ := strings.Cut(token, ".")
+	forged := s.issue(gameId, uuid.New(), now)
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+	_, _, err = s.verify(forgedPayload+"."+sig, now)
+	assert.ErrorIs(t, err, errInvalidToken)
+
+	for _, bad := range []string{"", ".", payload, "not.base64!", payload + "." + sig + "x"} {
+		_, _, err = s.verify(bad, now)
+		assert.ErrorIs(t, err, errInvalidToken, bad)
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/livingpool/top-down-shooter/game/pkg/protocol"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
+	"github.com/livingpool/top-down-shooter/game/util"
+)
+
+// delayedMsg is a message for the spectators, held back until the spectator delay has passed.
+type delayedMsg struct {
+	at  time.Time
+	msg state.Msg
+}
+
+// spectate subscribes a read-only viewer to the snapshots and events of the game given by game_id,
+// without adding a player to it. Spectators always get full snapshots, util.MaxSpectatorsPerGame at most.
+// Any message a spectator sends closes its connection.
+func (gs *GameServer) spectate(w http.ResponseWriter, r *http.Request) {
+	room, err := gs.getRoom(r.URL.Query().Get("game_id"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	room.mutex.Lock()
+	full := len(room.spectators) >= util.MaxSpectatorsPerGame
+	room.mutex.Unlock()
+	if full {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte("game has too many spectators"))
+		return
+	}
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		Subprotocols: protocol.Subprotocols,
+	})
+	if err != nil {
+		gs.logger.Error("error upgrading conn to a websocket", "err", err)
+		return
+	}
+	sub := subscriber{conn: conn, codec: protocol.ForSubprotocol(conn.Subprotocol()), out: newOutbox(util.ClientUpdateBufferSize, gs.slowClients)}
+
+	room.mutex.Lock()
+	// others may have started spectating since the check
+	if len(room.spectators) >= util.MaxSpectatorsPerGame {
+		room.mutex.Unlock()
+		conn.Close(websocket.StatusTryAgainLater, "game has too many spectators")
+		return
+	}
+	room.spectators[conn] = &sub
+	room.mutex.Unlock()
+
+	defer func() {
+		room.mutex.Lock()
+		delete(room.spectators, conn)
+		room.mutex.Unlock()
+		conn.CloseNow()
+	}()
+
+	gs.logger.Info("spectator joined", "game", room.game.ID)
+
+	// spectators are read-only, so reading only waits for the connection to close
+	ctx := conn.CloseRead(context.Background())
+	go gs.writePump(ctx, sub)
+
+	select {
+	case <-ctx.Done():
+	case <-room.done:
+		conn.Close(websocket.StatusGoingAway, "game closed")
+	}
+}
+
+// toSpectators queues a message for the spectators, see dueForSpectators.
+// Nothing is queued while nobody is spectating. The caller must hold r.mutex.
+func (r *room) toSpectators(msg state.Msg, now time.Time) {
+	if len(r.spectators) == 0 {
+		return
+	}
+	r.feed = append(r.feed, delayedMsg{at: now, msg: msg})
+}
+
+// dueForSpectators takes the queued messages that are at least delay old,
+// and returns them along with the spectators to send them to. The caller must hold r.mutex.
+func (r *room) dueForSpectators(now time.Time, delay time.Duration) ([]state.Msg, []subscriber) {
+	i := 0
+	for i < len(r.feed) && !r.feed[i].at.Add(delay).After(now) {
+		i++
+	}
+
+	msgs := make([]state.Msg, i)
+	for j := range i {
+		msgs[j] = r.feed[j].msg
+	}
+	r.feed = r.feed[i:]
+
+	subs := make([]subscriber, 0, len(r.spectators))
+	for _, sub := range r.spectators {
+		subs = append(subs, *sub)
+	}
+
+	return msgs, subs
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/livingpool/top-down-shooter/game/game"
+	"github.com/livingpool/top-down-shooter/game/pkg/state"
+	"github.com/livingpool/top-down-shooter/game/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpectatorFeed(t *testing.T) {
+	room := newRoom(game.NewGame(true))
+	now := time.Now()
+
+	// nobody is watching
+	room.toSpectators(state.Msg{Type: util.MsgTypeSnapshot}, now)
+	assert.Empty(t, room.feed)
+
+	room.spectators[&websocket.Conn{}] = &subscriber{}
+	for i := range 3 {
+		room.toSpectators(state.Msg{Type: util.MsgTypeSnapshot, Payload: state.ServerUpdate{Seq: i}}, now.Add(time.Duration(i)*time.Second))
+	}
+
+	msgs, subs := room.dueForSpectators(now.Add(2*time.Second), time.Second)
+	assert.Len(t, subs, 1)
+	assert.Equal(t, []state.Msg{
+		{Type: util.MsgTypeSnapshot, Payload: state.ServerUpdate{Seq: 0}},
+		{Type: util.MsgTypeSnapshot, Payload: state.ServerUpdate{Seq: 1}},
+	}, msgs, "only the messages at least a second old")
+	assert.Len(t, room.feed, 1)
+}
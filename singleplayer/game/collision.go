@@ -9,21 +9,35 @@ import (
 // TODO: locality? only resolve collision for objects near player / zombie, rather than iterating thru all of them
 
 func (g *Game) ResolveCollisions() {
-	var maxPenVect util.Vector // resolve deepest collision (max penetration vector)
+	// resolve deepest collision (max penetration vector)
+	if maxPenVect, yes := deepestCollision(*g.Player.Object, g.Background.Objects); yes {
+		slog.Info("collision player <-> background obj", "position", g.Player.Object.Center)
+		g.Player.Object.Center.Sub(maxPenVect)
+		g.Camera.Sub(maxPenVect)
+		slog.Info("adjusted position of camera & player", "position", g.Player.Object.Center)
+	}
+
+	// zombies are pushed out of whatever they walked into, the same way as the player,
+	// so they slide along walls while chasing instead of going through them
+	for _, z := range g.Spawner.Zombies() {
+		if vec, yes := deepestCollision(*z.Object, g.Background.Objects); yes {
+			z.Object.Center.Sub(vec)
+			slog.Debug("adjusted position of zombie", "position", z.Object.Center)
+		}
+	}
+}
+
+// deepestCollision returns the penetration vector of the deepest collision between obj and the others.
+func deepestCollision(obj util.GameObject, others []*util.GameObject) (util.Vector, bool) {
+	var maxPenVect util.Vector
 	var maxLen float64
-	for _, obj := range g.Background.Objects {
-		if vec, yes := g.Player.Object.Collide(*obj); yes {
-			slog.Info("collision player <-> background obj", "position", g.Player.Object.Center)
+	for _, other := range others {
+		if vec, yes := obj.Collide(*other); yes {
 			if l := vec.Length(); l > maxLen {
 				maxPenVect = vec
 				maxLen = l
 			}
 		}
 	}
-
-	if maxLen > 0 {
-		g.Player.Object.Center.Sub(maxPenVect)
-		g.Camera.Sub(maxPenVect)
-		slog.Info("adjusted position of camera & player", "position", g.Player.Object.Center)
-	}
+	return maxPenVect, maxLen > 0
 }
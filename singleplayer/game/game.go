@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/livingpool/top-down-shooter/game/pkg/clock"
 	"github.com/livingpool/top-down-shooter/singleplayer/pkg/background"
 	"github.com/livingpool/top-down-shooter/singleplayer/pkg/bullet"
 	"github.com/livingpool/top-down-shooter/singleplayer/pkg/player"
@@ -24,6 +25,8 @@ type Game struct {
 	Camera     *util.Camera // camera follows the player's movements, but centered at {0, 0} initially
 	Bullets    map[uuid.UUID]*bullet.Bullet
 	Spawner    *spawner.ZombieSpawner
+	Clock      clock.Clock // the real one, unless a test replaces it
+	frames     clock.Stopwatch
 }
 
 func NewGame(debugMode bool) *Game {
@@ -37,27 +40,33 @@ func NewGame(debugMode bool) *Game {
 	)))
 
 	camera := util.InitCamera()
+	bg := background.NewBackground()
 
 	return &Game{
 		DebugMode:  debugMode,
-		Background: background.NewBackground(),
+		Background: bg,
 		Player:     player.NewPlayer("You", camera),
 		Camera:     camera,
 		Bullets:    make(map[uuid.UUID]*bullet.Bullet),
-		Spawner:    spawner.NewZombieSpawner(5*time.Second, 3),
+		Spawner:    spawner.NewZombieSpawner(5*time.Second, 3, bg.NavGrid()),
+		Clock:      clock.Real{},
 	}
 }
 
 func (g *Game) Update() error {
-	newBullet := g.Player.Update(g.Camera)
+	// everything moves by the time that really elapsed since the last frame,
+	// so a slow frame doesn't slow the game down
+	dt := min(g.frames.Lap(g.Clock.Now()), util.MaxFrameDelta)
+
+	newBullet := g.Player.Update(g.Camera, dt)
 	for _, b := range g.Bullets {
-		b.Update()
+		b.Update(dt)
 	}
 	if newBullet != nil {
 		g.Bullets[uuid.New()] = newBullet
 	}
 
-	g.Spawner.Update(g.Player.Object.Center)
+	g.Spawner.Update(g.Player.Object.Center, dt)
 
 	g.ResolveCollisions()
 
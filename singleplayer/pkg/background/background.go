@@ -7,6 +7,7 @@ import (
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/livingpool/top-down-shooter/game/assets"
+	"github.com/livingpool/top-down-shooter/game/pkg/sim"
 	"github.com/livingpool/top-down-shooter/singleplayer/util"
 )
 
@@ -351,6 +352,45 @@ func NewBackground() *Background {
 	}
 }
 
+// b.NavGrid returns a navigation grid covering every object with a collider,
+// where the cells a zombie can't stand in without running into one are blocked.
+func (b *Background) NavGrid() *sim.Grid {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, obj := range b.Objects {
+		var reach float64 // how far the collider extends from its center in any direction
+		switch c := obj.Collider.(type) {
+		case util.Rect:
+			reach = math.Hypot(c.DimX, c.DimY) / 2
+		case util.Circle:
+			reach = c.Radius
+		default:
+			continue
+		}
+		minX, minY = min(minX, obj.Center.X-reach), min(minY, obj.Center.Y-reach)
+		maxX, maxY = max(maxX, obj.Center.X+reach), max(maxY, obj.Center.Y+reach)
+	}
+	if math.IsInf(minX, 1) {
+		return nil
+	}
+
+	origin := sim.Vector{X: minX - util.NavGridMargin, Y: minY - util.NavGridMargin}
+	cols := int(math.Ceil((maxX - minX + 2*util.NavGridMargin) / util.NavCellSize))
+	rows := int(math.Ceil((maxY - minY + 2*util.NavGridMargin) / util.NavCellSize))
+	grid := sim.NewGrid(origin, cols, rows, util.NavCellSize)
+
+	for _, obj := range b.Objects {
+		switch c := obj.Collider.(type) {
+		case util.Rect:
+			grid.BlockRect(c.Center.Sim(), c.DimX, c.DimY, c.Rotation, util.ZombieRadius)
+		case util.Circle:
+			grid.BlockCircle(c.Center.Sim(), c.Radius+util.ZombieRadius)
+		}
+	}
+
+	return grid
+}
+
 // b.Update updates every background objects' centers and their colliders' centers
 func (b *Background) Update() {
 }
@@ -1,11 +1,12 @@
 package bullet
 
 import (
-	"math"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/livingpool/top-down-shooter/game/assets"
+	"github.com/livingpool/top-down-shooter/game/pkg/sim"
 	"github.com/livingpool/top-down-shooter/singleplayer/util"
 )
 
@@ -28,11 +29,11 @@ func NewBullet(pos *util.Point, rotation float64) *Bullet {
 	}
 }
 
-func (b *Bullet) Update() {
-	speed := util.BulletSpeedPerSecond / float64(ebiten.TPS())
-
-	b.Object.Center.X += math.Sin(b.Object.Rotation) * speed
-	b.Object.Center.Y += math.Cos(b.Object.Rotation) * -speed
+// Update moves the bullet along its rotation for a duration of dt.
+func (b *Bullet) Update(dt time.Duration) {
+	s := sim.Bullet{Position: b.Object.Center.Sim(), Rotation: b.Object.Rotation}
+	s.Step(dt)
+	*b.Object.Center = util.PointOf(s.Position)
 }
 
 func (b *Bullet) Draw(screen *ebiten.Image, debugMode bool) {
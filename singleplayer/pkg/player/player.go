@@ -2,12 +2,13 @@ package player
 
 import (
 	"log/slog"
-	"math"
+	"time"
 
 	"github.com/coder/websocket"
 	"github.com/google/uuid"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/livingpool/top-down-shooter/game/assets"
+	"github.com/livingpool/top-down-shooter/game/pkg/sim"
 	"github.com/livingpool/top-down-shooter/singleplayer/pkg/bullet"
 	"github.com/livingpool/top-down-shooter/singleplayer/util"
 )
@@ -20,7 +21,7 @@ type Player struct {
 	LastDelta     util.Point // render rotation at the last frame to keep the facing position correctly
 	HumanoidState util.HumanoidState
 	Health        int
-	ShootCoolDown *util.Timer
+	ShootCoolDown time.Duration // until the player can shoot again
 	Ammo          int
 }
 
@@ -39,68 +40,53 @@ func NewPlayer(name string, camera *util.Camera) *Player {
 		LastDelta:     pos,
 		HumanoidState: util.HumanoidStateStand,
 		Health:        util.InitialPlayerHealth,
-		ShootCoolDown: util.NewTimer(util.PlayerShootCoolDown),
+		ShootCoolDown: util.PlayerShootCoolDown,
 		Ammo:          util.InitialPlayerAmmo,
 	}
 }
 
-// Player.Update() updates the player and returns a new Bullet (can be nil).
-func (p *Player) Update(camera *util.Camera) *bullet.Bullet {
-	// move 200 pixels per second
-	speed := float64(util.PlayerSpeedPerSecond / ebiten.TPS())
-
-	var b *bullet.Bullet
-
-	p.ShootCoolDown.Update()
-
-	var delta util.Point
-
-	if ebiten.IsKeyPressed(ebiten.KeyA) {
-		delta.X -= speed
-	}
-	if ebiten.IsKeyPressed(ebiten.KeyD) {
-		delta.X += speed
-	}
-	if ebiten.IsKeyPressed(ebiten.KeyW) {
-		delta.Y -= speed
-	}
-	if ebiten.IsKeyPressed(ebiten.KeyS) {
-		delta.Y += speed
+// Player.Update() moves the player by the keys pressed for a duration of dt,
+// and returns a new Bullet (can be nil).
+func (p *Player) Update(camera *util.Camera, dt time.Duration) *bullet.Bullet {
+	input := sim.Input{
+		W:     ebiten.IsKeyPressed(ebiten.KeyW),
+		S:     ebiten.IsKeyPressed(ebiten.KeyS),
+		A:     ebiten.IsKeyPressed(ebiten.KeyA),
+		D:     ebiten.IsKeyPressed(ebiten.KeyD),
+		Space: ebiten.IsKeyPressed(ebiten.KeySpace),
 	}
 
-	// check for diagonal movement
-	if delta.X != 0 && delta.Y != 0 {
-		factor := speed / math.Sqrt(delta.X*delta.X+delta.Y*delta.Y)
-		delta.X *= factor
-		delta.Y *= factor
+	s := sim.Player{
+		Position:  p.Object.Center.Sim(),
+		Rotation:  p.Object.Rotation,
+		LastDelta: p.LastDelta.Sim(),
+		Cooldown:  p.ShootCoolDown,
 	}
+	s.Cool(dt)
+	fired, ok := s.Step(input, dt)
 
-	p.Object.Center.X += delta.X
-	p.Object.Center.Y += delta.Y
-
+	// the camera follows the player
+	delta := s.Position.Sub(p.Object.Center.Sim())
 	camera.X += delta.X
 	camera.Y += delta.Y
 
-	// update rotation
-	if delta.X != 0 || delta.Y != 0 {
-		newRotation := math.Atan2(p.LastDelta.Y, p.LastDelta.X)
-		slog.Debug("rotation updated", "old", p.Object.Rotation, "new", newRotation)
-
-		p.Object.Rotation = newRotation
-		p.LastDelta = delta
+	if s.Rotation != p.Object.Rotation {
+		slog.Debug("rotation updated", "old", p.Object.Rotation, "new", s.Rotation)
 	}
 
-	// constrain shooting at fixed intervals
-	if p.ShootCoolDown.IsReady() && ebiten.IsKeyPressed(ebiten.KeySpace) {
-		p.ShootCoolDown.Reset()
+	*p.Object.Center = util.PointOf(s.Position)
+	p.Object.Rotation = s.Rotation
+	p.LastDelta = util.PointOf(s.LastDelta)
+	p.ShootCoolDown = s.Cooldown
 
-		spawnPos := p.Object.CalcBulletSpawnPosition()
-		b = bullet.NewBullet(&spawnPos, p.Object.Rotation+util.FacingOffset)
-
-		slog.Info("new bullet", "pos", spawnPos)
+	if !ok {
+		return nil
 	}
 
-	return b
+	spawnPos := util.PointOf(fired.Position)
+	slog.Info("new bullet", "pos", spawnPos)
+
+	return bullet.NewBullet(&spawnPos, fired.Rotation)
 }
 
 func (p *Player) Draw(screen *ebiten.Image, debugMode bool) {
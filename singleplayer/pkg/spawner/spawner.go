@@ -4,32 +4,37 @@ import (
 	"log/slog"
 	"math"
 	"math/rand"
+	"slices"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/livingpool/top-down-shooter/game/assets"
+	"github.com/livingpool/top-down-shooter/game/pkg/clock"
+	"github.com/livingpool/top-down-shooter/game/pkg/sim"
 	"github.com/livingpool/top-down-shooter/singleplayer/util"
 )
 
 type ZombieSpawner struct {
 	spawnDuration time.Duration
 	spawnCount    int
-	timer         *util.Timer
+	timer         *clock.Timer
 	zombies       []*Zombie
+	nav           *sim.Grid // zombies find their way around its blocked cells, can be nil
 }
 
-// Every duration `d`, spawn `count` zombies
-func NewZombieSpawner(d time.Duration, count int) *ZombieSpawner {
+// Every duration `d`, spawn `count` zombies, which route around the obstacles of nav.
+func NewZombieSpawner(d time.Duration, count int, nav *sim.Grid) *ZombieSpawner {
 	return &ZombieSpawner{
 		spawnDuration: d,
 		spawnCount:    count,
-		timer:         util.NewTimer(d),
+		timer:         clock.NewTimer(d),
 		zombies:       make([]*Zombie, 0, count),
+		nav:           nav,
 	}
 }
 
-func (zs *ZombieSpawner) Update(target *util.Point) {
-	zs.timer.Update()
+func (zs *ZombieSpawner) Update(target *util.Point, dt time.Duration) {
+	zs.timer.Update(dt)
 	if zs.timer.IsReady() {
 		for range zs.spawnCount {
 			pos := randPosition(util.ScreenWidth, util.ScreenHeight, util.Point{X: 0, Y: 0})
@@ -41,11 +46,22 @@ func (zs *ZombieSpawner) Update(target *util.Point) {
 		zs.timer.Reset()
 	}
 
-	for _, z := range zs.zombies {
-		z.Update(target)
+	positions := make([]sim.Vector, len(zs.zombies))
+	for i, z := range zs.zombies {
+		positions[i] = z.Object.Center.Sim()
+	}
+	for i, z := range zs.zombies {
+		// every zombie but z itself
+		neighbours := slices.Delete(slices.Clone(positions), i, i+1)
+		z.Update(target, neighbours, zs.nav, dt)
 	}
 }
 
+// Zombies returns every zombie spawned so far.
+func (zs *ZombieSpawner) Zombies() []*Zombie {
+	return zs.zombies
+}
+
 func (zs *ZombieSpawner) Draw(screen *ebiten.Image) {
 	for _, z := range zs.zombies {
 		bounds := z.Object.Sprite.Bounds()
@@ -85,6 +101,7 @@ type Zombie struct {
 	Health   int
 	Velocity float64
 	Target   *util.Point // for now this tracks the player's position
+	route    sim.Route   // the way to the target, around obstacles
 }
 
 func NewZombie(pos *util.Point, rot, velocity float64, health int, sprite *ebiten.Image, target *util.Point) *Zombie {
@@ -96,11 +113,20 @@ func NewZombie(pos *util.Point, rot, velocity float64, health int, sprite *ebite
 	}
 }
 
-// calc zombie's rotation wrt to the player's position
-func (z *Zombie) Update(target *util.Point) {
-	dx := target.X - z.Object.Center.X
-	dy := target.Y - z.Object.Center.Y
-	z.Object.Rotation = math.Atan2(dy, dx)
+// Update moves the zombie toward the target at its velocity for a duration of dt,
+// while keeping its distance from the neighbours. It follows a path around the obstacles of nav,
+// and is pushed out of whatever it still runs into by game.ResolveCollisions.
+func (z *Zombie) Update(target *util.Point, neighbours []sim.Vector, nav *sim.Grid, dt time.Duration) {
+	s := sim.Zombie{
+		Position: z.Object.Center.Sim(),
+		Rotation: z.Object.Rotation,
+		Speed:    z.Velocity,
+	}
+	waypoint := z.route.Next(nav, s.Position, target.Sim())
+	s.Chase(waypoint, neighbours, dt)
+
+	*z.Object.Center = util.PointOf(s.Position)
+	z.Object.Rotation = s.Rotation
 }
 
 // Generates a random speed
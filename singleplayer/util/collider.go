@@ -105,7 +105,7 @@ func (c Circle) Collide(other Collider) (Vector, bool) {
 		return c.IntersectCircleAndCircle(other)
 	case Rect:
 		v, yes := other.IntersectRectAndCircle(c)
-		return v.ReverseDirection(), yes
+		return v.Reverse(), yes
 	default:
 		log.Fatal("unrecognized collider type")
 		return Vector{}, false
@@ -142,9 +142,9 @@ func (r Rect) IntersectRectAndCircle(c Circle) (Vector, bool) {
 	for i := range 4 {
 		var axis Vector
 		if i != 3 {
-			axis = vertices[i].Vector(vertices[i+1]).GetPerpendicularVector()
+			axis = vertices[i].Vector(vertices[i+1]).Perpendicular()
 		} else {
-			axis = vertices[i].Vector(vertices[0]).GetPerpendicularVector()
+			axis = vertices[i].Vector(vertices[0]).Perpendicular()
 		}
 		axes = append(axes, axis.Normalize())
 	}
@@ -154,16 +154,16 @@ func (r Rect) IntersectRectAndCircle(c Circle) (Vector, bool) {
 
 	for _, axis := range axes {
 		// get r's 2 projected points onto the axis
-		max1 := axis.InnerProduct(Vector(vertices[0]))
+		max1 := axis.Dot(Vector(vertices[0]))
 		min1 := max1
 
 		for j := 1; j < 4; j++ {
-			max1 = math.Max(max1, axis.InnerProduct(Vector(vertices[j])))
-			min1 = math.Min(min1, axis.InnerProduct(Vector(vertices[j])))
+			max1 = math.Max(max1, axis.Dot(Vector(vertices[j])))
+			min1 = math.Min(min1, axis.Dot(Vector(vertices[j])))
 		}
 
 		// get c's 2 projected points onto the axis
-		cent := axis.InnerProduct(Vector(*c.Center))
+		cent := axis.Dot(Vector(*c.Center))
 		max2 := cent + c.Radius
 		min2 := cent - c.Radius
 
@@ -193,7 +193,7 @@ func (r Rect) IntersectRectAndCircle(c Circle) (Vector, bool) {
 	var test = Circle{Center: &Point{c.Center.X, c.Center.Y}}
 	test.Center.Add(offsetVector)
 	if test.Center.ManhattanDistance(*r.Center) < c.Center.ManhattanDistance(*r.Center) {
-		offsetVector = offsetVector.ReverseDirection()
+		offsetVector = offsetVector.Reverse()
 	}
 
 	slog.Info("rect circle collision", "r", r.Center, "c", c.Center, "offset", smallestOverlap, "vector", offsetVector)
@@ -212,27 +212,27 @@ func (r Rect) IntersectRectAndRect(other Rect) (Vector, bool) {
 	for i := range 4 {
 		var axis Vector
 		if i != 3 {
-			axis = vertices1[i].Vector(vertices1[i+1]).GetPerpendicularVector().Normalize()
+			axis = vertices1[i].Vector(vertices1[i+1]).Perpendicular().Normalize()
 		} else {
-			axis = vertices1[i].Vector(vertices1[0]).GetPerpendicularVector().Normalize()
+			axis = vertices1[i].Vector(vertices1[0]).Perpendicular().Normalize()
 		}
 
 		// get r's 2 projected points onto the axis
-		max1 := axis.InnerProduct(Vector(vertices1[0]))
+		max1 := axis.Dot(Vector(vertices1[0]))
 		min1 := max1
 
 		for j := 1; j < 4; j++ {
-			max1 = math.Max(max1, axis.InnerProduct(Vector(vertices1[j])))
-			min1 = math.Min(min1, axis.InnerProduct(Vector(vertices1[j])))
+			max1 = math.Max(max1, axis.Dot(Vector(vertices1[j])))
+			min1 = math.Min(min1, axis.Dot(Vector(vertices1[j])))
 		}
 
 		// get other's 2 projected points onto the axis
-		max2 := axis.InnerProduct(Vector(vertices2[0]))
+		max2 := axis.Dot(Vector(vertices2[0]))
 		min2 := max2
 
 		for j := 1; j < 4; j++ {
-			max2 = math.Max(max2, axis.InnerProduct(Vector(vertices2[j])))
-			min2 = math.Min(min2, axis.InnerProduct(Vector(vertices2[j])))
+			max2 = math.Max(max2, axis.Dot(Vector(vertices2[j])))
+			min2 = math.Min(min2, axis.Dot(Vector(vertices2[j])))
 		}
 
 		slog.Debug("rect rect projected points", "axis", axis, "min1", min1, "max1", max1, "min2", min2, "max2", max2)
@@ -261,7 +261,7 @@ func (r Rect) IntersectRectAndRect(other Rect) (Vector, bool) {
 	var test = Rect{Center: &Point{other.Center.X, other.Center.Y}}
 	test.Center.Add(offsetVector)
 	if test.Center.ManhattanDistance(*r.Center) < other.Center.ManhattanDistance(*r.Center) {
-		offsetVector = offsetVector.ReverseDirection()
+		offsetVector = offsetVector.Reverse()
 	}
 
 	slog.Info("rect rect collision", "r", r.Center, "other", other.Center, "offset", smallestOverlap, "vector", offsetVector)
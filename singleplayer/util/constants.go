@@ -1,8 +1,9 @@
 package util
 
 import (
-	"math"
 	"time"
+
+	"github.com/livingpool/top-down-shooter/game/pkg/sim"
 )
 
 // Game settings
@@ -11,13 +12,14 @@ const (
 	ScreenHeight        = 600
 	ServerPhysicsPeriod = 15 * time.Millisecond
 	ServerUpdatePeriod  = 45 * time.Millisecond
+	MaxFrameDelta       = 100 * time.Millisecond // a frame never simulates longer than this, e.g. after the window was dragged
 )
 
-// Position offsets
+// Position offsets, see sim
 const (
-	FacingOffset      = 90.0 * math.Pi / 180.0
-	GunPointOffset    = 20.0 * math.Pi / 180.0
-	BulletSpawnOffset = 30.0
+	FacingOffset      = sim.FacingOffset
+	GunPointOffset    = sim.GunPointOffset
+	BulletSpawnOffset = sim.BulletSpawnOffset
 )
 
 // Initial player states
@@ -26,7 +28,7 @@ const (
 	InitialPlayerAmmo     = 10
 	InitialPlayerX        = ScreenWidth / 2
 	InitialPlayerY        = ScreenHeight/2 + 50
-	InitialPlayerRotation = -FacingOffset
+	InitialPlayerRotation = sim.InitialPlayerRotation
 )
 
 // All the different states a humanoid can be in
@@ -41,19 +43,26 @@ const (
 	HumanoidStateStand
 )
 
-// Player settings
+// Player settings, see sim
+const (
+	PlayerSpeedPerSecond = sim.PlayerSpeedPerSecond
+	PlayerShootCoolDown  = sim.PlayerShootCoolDown
+)
+
+// Bullet settings, see sim
 const (
-	PlayerSpeedPerSecond = 200 // move x pixels per second
-	PlayerShootCoolDown  = 500 * time.Millisecond
+	BulletSpeedPerSecond = sim.BulletSpeedPerSecond
 )
 
-// Bullet settings
+// Navigation settings, see sim.Grid
 const (
-	BulletSpeedPerSecond = 350.0
+	NavCellSize   = 32.0
+	NavGridMargin = 8 * NavCellSize // how far the grid extends past the outermost obstacles
+	ZombieRadius  = 32.0            // of the zombies' circle collider, obstacles are grown by it
 )
 
-// Zombie spawner settings
+// Zombie spawner settings, see sim
 const (
-	ZombieMaxSpeedPerSecond = 300
-	ZombieMinSpeedPerSecond = 100
+	ZombieMaxSpeedPerSecond = sim.ZombieMaxSpeedPerSecond
+	ZombieMinSpeedPerSecond = sim.ZombieMinSpeedPerSecond
 )
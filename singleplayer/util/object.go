@@ -8,6 +8,7 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/livingpool/top-down-shooter/game/pkg/sim"
 )
 
 type Camera Point
@@ -75,18 +76,6 @@ func (obj GameObject) CenterAndRotateImage() *ebiten.DrawImageOptions {
 	return op
 }
 
-// Calculates the bullet's spawn position relative to the object.
-// Note that we need to offset bullet's rotation due to object's facing direction.
-// Maybe I would extract that out in the future.
-func (obj GameObject) CalcBulletSpawnPosition() Point {
-	spawnRotation := obj.Rotation + GunPointOffset
-	spawnPos := Point{
-		X: obj.Center.X + math.Cos(spawnRotation)*BulletSpawnOffset,
-		Y: obj.Center.Y + math.Sin(spawnRotation)*BulletSpawnOffset,
-	}
-	return spawnPos
-}
-
 func (obj GameObject) DrawDebugCircle(screen *ebiten.Image, radius float32, debugText string) {
 	GameCamera.WorldToScreen(*obj.Center).DrawDebugCircle(screen, radius)
 	if debugText != "" {
@@ -146,48 +135,20 @@ func (p Point) DrawDebugRect(screen *ebiten.Image, dimX, dimY float32) {
 	vector.StrokeRect(screen, originX, originY, dimX, dimY, 1, c, true)
 }
 
-// p.Vector returns a Vector p -> other
-func (p Point) Vector(other Point) Vector {
-	return Vector{other.X - p.X, other.Y - p.Y}
-}
-
-type Vector struct {
-	X float64
-	Y float64
-}
-
-func (v *Vector) Add(other Vector) {
-	v.X += other.X
-	v.Y += other.Y
+// p.Sim converts p to a position in the simulation.
+func (p Point) Sim() sim.Vector {
+	return sim.Vector{X: p.X, Y: p.Y}
 }
 
-func (v Vector) Length() float64 {
-	return math.Sqrt(v.X*v.X + v.Y*v.Y)
+// PointOf converts a position in the simulation to a Point.
+func PointOf(v sim.Vector) Point {
+	return Point{X: v.X, Y: v.Y}
 }
 
-func (v Vector) ReverseDirection() Vector {
-	return Vector{-v.X, -v.Y}
-}
-
-func (v Vector) Normalize() Vector {
-	magnitude := v.Length()
-	if magnitude == 0 {
-		log.Fatalf("vector %v has length = 0, returning it as is...", v)
-	}
-	return Vector{v.X / magnitude, v.Y / magnitude}
-}
-
-func (v Vector) Scale(n float64) Vector {
-	if n == 0 {
-		log.Fatal("scaling to zero is not allowed")
-	}
-	return Vector{v.X * n, v.Y * n}
-}
-
-func (v Vector) GetPerpendicularVector() Vector {
-	return Vector{-v.Y, v.X}
+// p.Vector returns a Vector p -> other
+func (p Point) Vector(other Point) Vector {
+	return Vector{X: other.X - p.X, Y: other.Y - p.Y}
 }
 
-func (v Vector) InnerProduct(other Vector) float64 {
-	return v.X*other.X + v.Y*other.Y
-}
+// Vector is the simulation's, so the singleplayer game shares its math with the networked one.
+type Vector = sim.Vector